@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/config"
+)
+
+const configUsage = "usage: config print"
+
+// runConfig handle "config print" subcommand
+func runConfig(cfg *config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	out, err := json.MarshalIndent(cfg.Redacted(), "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "json.MarshalIndent")
+	}
+	fmt.Fprintln(os.Stdout, string(out))
+
+	return cfg.Validate()
+}
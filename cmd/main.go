@@ -2,16 +2,23 @@ package main
 
 import (
 	"context"
+	"flag"
 	"github.com/Lidne/praktika_MAI/config"
 	_ "github.com/Lidne/praktika_MAI/docs"
 	"github.com/Lidne/praktika_MAI/internal/server"
+	"github.com/Lidne/praktika_MAI/pkg/buildinfo"
 	"github.com/Lidne/praktika_MAI/pkg/jaeger"
 	"github.com/Lidne/praktika_MAI/pkg/kafka"
+	"github.com/Lidne/praktika_MAI/pkg/lifecycle"
 	"github.com/Lidne/praktika_MAI/pkg/logger"
 	"github.com/Lidne/praktika_MAI/pkg/postgres"
+	"github.com/Lidne/praktika_MAI/pkg/redis"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/opentracing/opentracing-go"
 	"log"
+	"os"
+	"time"
+	_ "time/tzdata"
 )
 
 type App struct {
@@ -36,9 +43,12 @@ func NewApp(pool *pgxpool.Pool) *App {
 // @license.url   http://www.apache.org/licenses/LICENSE-2.0.html
 
 // @host      localhost:5007
-// @BasePath  /api/
+// @BasePath  /
 
-// @securityDefinitions.basic  BasicAuth
+// @securityDefinitions.apikey  BearerAuth
+// @in                          header
+// @name                        Authorization
+// @description                 Access token from /api/auth/login, sent as "Bearer <token>"
 
 // @externalDocs.description  OpenAPI
 // @externalDocs.url          https://swagger.io/resources/open-api/
@@ -46,22 +56,51 @@ func main() {
 	log.Println("Starting products microservice")
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	cfg, err := config.ParseConfig()
+	configPath := flag.String("config", "", "path to the config file, overrides MODE")
+	flag.Parse()
+	args := flag.Args()
+
+	cfg, err := config.ParseConfig(*configPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	if len(args) > 0 && args[0] == "config" {
+		if err := runConfig(cfg, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
 	appLogger := logger.NewApiLogger(cfg)
 	appLogger.InitLogger()
 	appLogger.Info("Starting user server")
+	build := buildinfo.Get(cfg.AppVersion)
 	appLogger.Infof(
-		"AppVersion: %s, LogLevel: %s, DevelopmentMode: %s",
+		"AppVersion: %s, Version: %s, Revision: %s, LogLevel: %s, DevelopmentMode: %s",
 		cfg.AppVersion,
+		build.Version,
+		build.Revision,
 		cfg.Logger.Level,
 		cfg.Server.Development,
 	)
 	appLogger.Infof("Success parsed config: %#v", cfg.AppVersion)
 
+	if len(args) > 0 && args[0] == "migrate" {
+		dbpool, err := postgres.NewClient(ctx, cfg)
+		if err != nil {
+			appLogger.Fatal("postgres.NewClient", err)
+		}
+		defer dbpool.Close()
+		if err := runMigrate(ctx, dbpool, appLogger, args[1:]); err != nil {
+			appLogger.Fatal(err)
+		}
+		return
+	}
+
 	tracer, closer, err := jaeger.InitJaeger(cfg)
 	if err != nil {
 		appLogger.Fatal("cannot create tracer", err)
@@ -69,24 +108,53 @@ func main() {
 	appLogger.Info("Jaeger connected")
 
 	opentracing.SetGlobalTracer(tracer)
-	defer closer.Close()
 	appLogger.Info("Opentracing connected")
 
 	dbpool, err := postgres.NewClient(ctx, cfg)
-	defer dbpool.Close()
+	if err != nil {
+		appLogger.Fatal("postgres.NewClient", err)
+	}
 	appLogger.Info("PostgreSQL connected")
 
+	if cfg.Postgres.AutoMigrate {
+		if err := runMigrate(ctx, dbpool, appLogger, []string{"up"}); err != nil {
+			appLogger.Fatal("runMigrate", err)
+		}
+	}
+
 	conn, err := kafka.NewKafkaConn(cfg)
 	if err != nil {
 		appLogger.Fatal("NewKafkaConn", err)
 	}
-	defer conn.Close()
 	brokers, err := conn.Brokers()
 	if err != nil {
 		appLogger.Fatal("conn.Brokers", err)
 	}
 	appLogger.Infof("Kafka connected: %v", brokers)
 
-	s := server.NewServer(appLogger, cfg, tracer, dbpool)
-	appLogger.Fatal(s.Run())
+	redisClient := redis.NewRedisClient(cfg)
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		appLogger.Warnf("Redis ping: %v", err)
+	} else {
+		appLogger.Info("Redis connected")
+	}
+
+	lc := lifecycle.NewManager(appLogger, cfg.Server.ShutdownTimeout*time.Second)
+	s := server.NewServer(appLogger, cfg, tracer, dbpool, redisClient)
+	if err := s.Register(lc); err != nil {
+		appLogger.Fatal("server.Register", err)
+	}
+	// closed after every server and worker is stopped, the tracer last to flush their spans
+	lc.OnClose("postgres", func() error {
+		dbpool.Close()
+		return nil
+	})
+	lc.OnClose("redis", redisClient.Close)
+	lc.OnClose("kafka", conn.Close)
+	lc.OnClose("jaeger", closer.Close)
+
+	if err := lc.Run(ctx); err != nil {
+		appLogger.Error(err)
+		os.Exit(1)
+	}
 }
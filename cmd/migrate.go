@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/migrations"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+	"github.com/Lidne/praktika_MAI/pkg/migrate"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// runMigrate handle "migrate up|down [steps]|status" subcommand
+func runMigrate(ctx context.Context, pool *pgxpool.Pool, log logger.Logger, args []string) error {
+	migrator, err := migrate.NewMigrator(pool, log, migrations.FS)
+	if err != nil {
+		return errors.Wrap(err, "migrate.NewMigrator")
+	}
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			return errors.Wrap(err, "migrator.Up")
+		}
+		log.Infof("Applied %d migrations", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return errors.New(migrateUsage)
+			}
+		}
+		count, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return errors.Wrap(err, "migrator.Down")
+		}
+		log.Infof("Rolled back %d migrations", count)
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return errors.Wrap(err, "migrator.Status")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, m := range status {
+			appliedAt := "pending"
+			if m.AppliedAt != nil {
+				appliedAt = m.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", m.Version, m.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
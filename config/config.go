@@ -1,16 +1,22 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
 
 const (
-	GRPC_PORT = "GRPC_PORT"
-	HTTP_PORT = "HTTP_PORT"
+	GRPC_PORT  = "GRPC_PORT"
+	HTTP_PORT  = "HTTP_PORT"
+	ENV_PREFIX = "APP"
 )
 
 // Config of application
@@ -24,9 +30,12 @@ type Config struct {
 	Kafka      Kafka
 	Http       Http
 	Redis      Redis
+	Cache      Cache
+	Health     Health
+	Outbox     Outbox
 }
 
-// Server config
+// Server config, ShutdownTimeout is in seconds
 type Server struct {
 	Port              string
 	Development       bool
@@ -35,17 +44,37 @@ type Server struct {
 	WriteTimeout      time.Duration
 	MaxConnectionIdle time.Duration
 	MaxConnectionAge  time.Duration
+	ShutdownTimeout   time.Duration
 	Kafka             Kafka
+	TLS               TLS
 }
 
+// Http config, timeouts, CookieLifeTime, JwtExpire and CsrfExpire are in seconds
 type Http struct {
 	Port              string
 	PprofPort         string
+	PprofEnabled      bool
 	Timeout           time.Duration
 	ReadTimeout       time.Duration
 	WriteTimeout      time.Duration
 	CookieLifeTime    int
 	SessionCookieName string
+	JwtSecretKey      string `secret:"true"`
+	JwtExpire         time.Duration
+	CsrfSecretKey     string `secret:"true"`
+	CsrfExpire        time.Duration
+	TLS               TLS
+}
+
+// TLS config of a server, certificates are reloaded on SIGHUP.
+// ClientCAFile enables mutual TLS, MinVersion is "1.2" or "1.3", CipherSuites are crypto/tls names
+type TLS struct {
+	Enabled      bool
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	MinVersion   string
+	CipherSuites []string
 }
 
 // Logger config
@@ -71,11 +100,12 @@ type Jaeger struct {
 }
 
 type Postgres struct {
-	Host     string
-	User     string
-	Password string
-	Port     string
-	DB       string
+	Host        string
+	User        string
+	Password    string `secret:"true"`
+	Port        string
+	DB          string
+	AutoMigrate bool
 }
 
 type Kafka struct {
@@ -84,39 +114,101 @@ type Kafka struct {
 
 type Redis struct {
 	RedisAddr      string
-	RedisPassword  string
+	RedisPassword  string `secret:"true"`
 	RedisDB        string
 	RedisDefaultDB string
 	MinIdleConn    int
 	PoolSize       int
 	PoolTimeout    int
-	Password       string
+	Password       string `secret:"true"`
 	DB             int
 }
 
-func exportConfig() error {
+// Cache read-through cache config, TTLs are in seconds
+type Cache struct {
+	Enabled    bool
+	ProductTTL time.Duration
+	UserTTL    time.Duration
+	ListTTL    time.Duration
+}
+
+// Health readiness checks config, durations are in seconds
+type Health struct {
+	Timeout  time.Duration
+	CacheTTL time.Duration
+}
+
+// Outbox relay config, sent events older than Retention are purged every PurgeInterval, both are in seconds
+type Outbox struct {
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+func exportConfig(path string) error {
 	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./config")
-	if os.Getenv("MODE") == "DOCKER" {
-		viper.SetConfigName("config-docker.yml")
+	if path != "" {
+		viper.SetConfigFile(path)
 	} else {
-		viper.SetConfigName("config.yaml")
+		viper.AddConfigPath("./config")
+		if os.Getenv("MODE") == "DOCKER" {
+			viper.SetConfigName("config-docker.yml")
+		} else {
+			viper.SetConfigName("config.yaml")
+		}
 	}
 
 	if err := viper.ReadInConfig(); err != nil {
 		return err
 	}
+	bindEnv(reflect.TypeOf(Config{}), nil)
 	return nil
 }
 
-// ParseConfig Parse config file
-func ParseConfig() (*Config, error) {
-	if err := exportConfig(); err != nil {
+// bindEnv bind every config field to an ENV_PREFIX_SECTION_FIELD variable,
+// e.g. Http.Port to APP_HTTP_PORT, lists are comma separated
+func bindEnv(t reflect.Type, path []string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldPath := append(append([]string{}, path...), field.Name)
+		if field.Type.Kind() == reflect.Struct {
+			bindEnv(field.Type, fieldPath)
+			continue
+		}
+		key := strings.Join(fieldPath, ".")
+		_ = viper.BindEnv(key, envName(key))
+	}
+}
+
+// envName env variable overriding the config key, e.g. APP_HTTP_PORT for Http.Port
+func envName(key string) string {
+	return ENV_PREFIX + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// numberToDurationHook decode durations from plain numbers, env variables are strings while
+// the file has ints, both are in the units of the field (mostly seconds) rather than nanoseconds
+func numberToDurationHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if to != reflect.TypeOf(time.Duration(0)) || from.Kind() != reflect.String {
+		return data, nil
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(data.(string)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q, expected a whole number", data)
+	}
+	return time.Duration(n), nil
+}
+
+// ParseConfig Parse config file, the default file is chosen by MODE when path is empty.
+// Every field can be overridden by environment variables, see bindEnv
+func ParseConfig(path string) (*Config, error) {
+	if err := exportConfig(path); err != nil {
 		return nil, err
 	}
 
 	var c Config
-	err := viper.Unmarshal(&c)
+	err := viper.Unmarshal(&c, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		numberToDurationHook,
+		mapstructure.StringToSliceHookFunc(","),
+	)))
 	if err != nil {
 		log.Printf("unable to decode into struct, %v", err)
 		return nil, err
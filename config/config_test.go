@@ -0,0 +1,208 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func validConfig() *Config {
+	return &Config{
+		Server: Server{Port: ":5000", Timeout: 15, MaxConnectionIdle: 5, MaxConnectionAge: 5, ShutdownTimeout: 10},
+		Http: Http{
+			Port:              ":5007",
+			ReadTimeout:       5,
+			WriteTimeout:      5,
+			SessionCookieName: "session-id",
+			CookieLifeTime:    3600,
+			JwtSecretKey:      testSecret,
+			JwtExpire:         900,
+			CsrfSecretKey:     testSecret + "-csrf",
+			CsrfExpire:        900,
+		},
+		Metrics:  Metrics{Port: ":7070"},
+		Logger:   Logger{Level: "info", Encoding: "json"},
+		Postgres: Postgres{Host: "localhost", Port: "5432", User: "postgres", Password: "postgres", DB: "products"},
+		Kafka:    Kafka{Brokers: []string{"localhost:9092"}},
+		Redis:    Redis{RedisPassword: "redis", Password: "redis"},
+		Health:   Health{Timeout: 2, CacheTTL: 5},
+		Outbox:   Outbox{Retention: 604800, PurgeInterval: 3600},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string
+	}{
+		{name: "valid", modify: func(c *Config) {}},
+		{
+			name:   "empty secrets",
+			modify: func(c *Config) { c.Http.JwtSecretKey, c.Http.CsrfSecretKey = "", "" },
+			want: []string{
+				"Http.JwtSecretKey: must not be empty, set it with the APP_HTTP_JWTSECRETKEY env variable",
+				"Http.CsrfSecretKey: must not be empty, set it with the APP_HTTP_CSRFSECRETKEY env variable",
+			},
+		},
+		{
+			name:   "placeholder secret",
+			modify: func(c *Config) { c.Http.JwtSecretKey = "Change_Me_In_Production" },
+			want:   []string{"Http.JwtSecretKey: must not be a placeholder value"},
+		},
+		{
+			name:   "short secret",
+			modify: func(c *Config) { c.Http.CsrfSecretKey = testSecret[:31] },
+			want:   []string{"Http.CsrfSecretKey: must be at least 32 bytes long"},
+		},
+		{
+			name: "short secret in development",
+			modify: func(c *Config) {
+				c.Server.Development = true
+				c.Http.JwtSecretKey, c.Http.CsrfSecretKey = "secret", "dev"
+			},
+		},
+		{
+			name: "empty secret in development",
+			modify: func(c *Config) {
+				c.Server.Development = true
+				c.Http.JwtSecretKey = ""
+			},
+			want: []string{"Http.JwtSecretKey: must not be empty, set it with the APP_HTTP_JWTSECRETKEY env variable"},
+		},
+		{
+			name: "addresses",
+			modify: func(c *Config) {
+				c.Http.Port = "5007"
+				c.Server.Port = ":70000"
+				c.Kafka.Brokers = []string{"localhost:9092", "kafka"}
+			},
+			want: []string{
+				`Server.Port: invalid port "70000"`,
+				`Http.Port: invalid address "5007", expected [host]:port`,
+				`Kafka.Brokers[1]: invalid address "kafka", expected [host]:port`,
+			},
+		},
+		{
+			name: "durations",
+			modify: func(c *Config) {
+				c.Server.ShutdownTimeout = 0
+				c.Health.CacheTTL = -1
+				c.Outbox.Retention = 0
+			},
+			want: []string{
+				"Server.ShutdownTimeout: must be greater than 0",
+				"Health.CacheTTL: must not be negative",
+				"Outbox.Retention: must be greater than 0",
+			},
+		},
+		{
+			name:   "cache ttls are checked only when enabled",
+			modify: func(c *Config) { c.Cache = Cache{Enabled: true, ProductTTL: 300} },
+			want:   []string{"Cache.UserTTL: must be greater than 0", "Cache.ListTTL: must be greater than 0"},
+		},
+		{
+			name: "logger",
+			modify: func(c *Config) {
+				c.Logger.Level = "verbose"
+				c.Logger.Encoding = "xml"
+			},
+			want: []string{`Logger.Level: unknown level "verbose"`, `Logger.Encoding: unknown encoding "xml", expected json or console`},
+		},
+		{
+			name: "tls",
+			modify: func(c *Config) {
+				c.Http.TLS = TLS{Enabled: true, MinVersion: "1.4", CipherSuites: []string{"TLS_NOT_A_SUITE"}}
+			},
+			want: []string{
+				"Http.TLS.CertFile: must not be empty",
+				"Http.TLS.KeyFile: must not be empty",
+				"Http.TLS.MinVersion:",
+				"Http.TLS.CipherSuites:",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.modify(c)
+			err := c.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+			if len(validationErr.Problems) != len(tt.want) {
+				t.Fatalf("Problems = %q, want %q", validationErr.Problems, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(validationErr.Problems[i], want) {
+					t.Errorf("Problems[%d] = %q, want %q", i, validationErr.Problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	c := validConfig()
+	c.Redis.RedisPassword = ""
+	c.Http.TLS.CipherSuites = []string{"TLS_AES_128_GCM_SHA256"}
+
+	r := c.Redacted()
+
+	for name, got := range map[string]string{
+		"Http.JwtSecretKey":  r.Http.JwtSecretKey,
+		"Http.CsrfSecretKey": r.Http.CsrfSecretKey,
+		"Postgres.Password":  r.Postgres.Password,
+		"Redis.Password":     r.Redis.Password,
+	} {
+		if got != redacted {
+			t.Errorf("%s = %q, want %q", name, got, redacted)
+		}
+	}
+	if r.Redis.RedisPassword != "" {
+		t.Errorf("Redis.RedisPassword = %q, empty secrets stay empty", r.Redis.RedisPassword)
+	}
+	if r.Postgres.User != c.Postgres.User || r.Http.Port != c.Http.Port {
+		t.Errorf("fields without the secret tag were changed: %+v", r)
+	}
+
+	if c.Http.JwtSecretKey != testSecret || c.Postgres.Password != "postgres" {
+		t.Errorf("Redacted() changed the original config")
+	}
+	r.Kafka.Brokers[0] = "changed:9092"
+	r.Http.TLS.CipherSuites[0] = "changed"
+	if c.Kafka.Brokers[0] != "localhost:9092" || c.Http.TLS.CipherSuites[0] != "TLS_AES_128_GCM_SHA256" {
+		t.Errorf("Redacted() shares slices with the original config")
+	}
+}
+
+func TestParseConfigFiles(t *testing.T) {
+	t.Setenv("APP_HTTP_JWTSECRETKEY", testSecret)
+	t.Setenv("APP_HTTP_CSRFSECRETKEY", testSecret+"-csrf")
+
+	for _, path := range []string{"config.yaml", "config-docker.yml"} {
+		t.Run(path, func(t *testing.T) {
+			c, err := ParseConfig(path)
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			if err := c.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if c.Http.JwtSecretKey != testSecret {
+				t.Errorf("Http.JwtSecretKey was not read from APP_HTTP_JWTSECRETKEY")
+			}
+		})
+	}
+}
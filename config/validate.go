@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lidne/praktika_MAI/pkg/tlsconfig"
+)
+
+const (
+	redacted        = "******"
+	minSecretLength = 32
+)
+
+var (
+	logLevels    = map[string]bool{"debug": true, "info": true, "warn": true, "error": true, "dpanic": true, "panic": true, "fatal": true}
+	logEncodings = map[string]bool{"json": true, "console": true}
+	// placeholderSecrets values that were once committed or are commonly copied from examples
+	placeholderSecrets = map[string]bool{
+		"change_me_in_production":     true,
+		"change_me_in_production_too": true,
+		"changeme":                    true,
+		"secret":                      true,
+	}
+)
+
+// ValidationError every problem found in the config
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addf(field, format string, args ...interface{}) {
+	v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) address(field, value string) {
+	_, port, err := net.SplitHostPort(value)
+	if err != nil {
+		v.addf(field, "invalid address %q, expected [host]:port", value)
+		return
+	}
+	if n, err := strconv.Atoi(port); err != nil || n <= 0 || n > 65535 {
+		v.addf(field, "invalid port %q", port)
+	}
+}
+
+func (v *validator) positive(field string, value time.Duration) {
+	if value <= 0 {
+		v.addf(field, "must be greater than 0")
+	}
+}
+
+func (v *validator) tls(field string, value TLS) {
+	if !value.Enabled {
+		return
+	}
+	v.required(field+".CertFile", value.CertFile)
+	v.required(field+".KeyFile", value.KeyFile)
+	if _, err := tlsconfig.ParseVersion(value.MinVersion); err != nil {
+		v.addf(field+".MinVersion", "%v", err)
+	}
+	if _, err := tlsconfig.ParseCipherSuites(value.CipherSuites); err != nil {
+		v.addf(field+".CipherSuites", "%v", err)
+	}
+}
+
+// secret signing key, outside development it must not be a known placeholder and must be long enough
+func (v *validator) secret(field, value string, development bool) {
+	switch {
+	case value == "":
+		v.addf(field, "must not be empty, set it with the %s env variable", envName(field))
+	case development:
+	case placeholderSecrets[strings.ToLower(value)]:
+		v.addf(field, "must not be a placeholder value")
+	case len(value) < minSecretLength:
+		v.addf(field, "must be at least %d bytes long", minSecretLength)
+	}
+}
+
+func (v *validator) required(field, value string) {
+	if value == "" {
+		v.addf(field, "must not be empty")
+	}
+}
+
+// Validate check the config and report every problem at once
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.address("Server.Port", c.Server.Port)
+	v.positive("Server.Timeout", c.Server.Timeout)
+	v.positive("Server.MaxConnectionIdle", c.Server.MaxConnectionIdle)
+	v.positive("Server.MaxConnectionAge", c.Server.MaxConnectionAge)
+	v.positive("Server.ShutdownTimeout", c.Server.ShutdownTimeout)
+	v.tls("Server.TLS", c.Server.TLS)
+
+	v.address("Http.Port", c.Http.Port)
+	v.positive("Http.ReadTimeout", c.Http.ReadTimeout)
+	v.positive("Http.WriteTimeout", c.Http.WriteTimeout)
+	v.required("Http.SessionCookieName", c.Http.SessionCookieName)
+	if c.Http.CookieLifeTime <= 0 {
+		v.addf("Http.CookieLifeTime", "must be greater than 0")
+	}
+	v.secret("Http.JwtSecretKey", c.Http.JwtSecretKey, c.Server.Development)
+	v.positive("Http.JwtExpire", c.Http.JwtExpire)
+	v.secret("Http.CsrfSecretKey", c.Http.CsrfSecretKey, c.Server.Development)
+	v.positive("Http.CsrfExpire", c.Http.CsrfExpire)
+	v.tls("Http.TLS", c.Http.TLS)
+
+	if c.Http.PprofEnabled {
+		v.address("Http.PprofPort", c.Http.PprofPort)
+	}
+
+	v.address("Metrics.Port", c.Metrics.Port)
+
+	if !logLevels[c.Logger.Level] {
+		v.addf("Logger.Level", "unknown level %q", c.Logger.Level)
+	}
+	if !logEncodings[c.Logger.Encoding] {
+		v.addf("Logger.Encoding", "unknown encoding %q, expected json or console", c.Logger.Encoding)
+	}
+
+	v.required("Postgres.Host", c.Postgres.Host)
+	v.required("Postgres.Port", c.Postgres.Port)
+	v.required("Postgres.User", c.Postgres.User)
+	v.required("Postgres.DB", c.Postgres.DB)
+
+	if len(c.Kafka.Brokers) == 0 {
+		v.addf("Kafka.Brokers", "must not be empty")
+	}
+	for i, broker := range c.Kafka.Brokers {
+		v.address(fmt.Sprintf("Kafka.Brokers[%d]", i), broker)
+	}
+
+	if c.Cache.Enabled {
+		v.positive("Cache.ProductTTL", c.Cache.ProductTTL)
+		v.positive("Cache.UserTTL", c.Cache.UserTTL)
+		v.positive("Cache.ListTTL", c.Cache.ListTTL)
+	}
+
+	v.positive("Health.Timeout", c.Health.Timeout)
+	if c.Health.CacheTTL < 0 {
+		v.addf("Health.CacheTTL", "must not be negative")
+	}
+
+	v.positive("Outbox.Retention", c.Outbox.Retention)
+	v.positive("Outbox.PurgeInterval", c.Outbox.PurgeInterval)
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// Redacted copy of the config with fields tagged secret:"true" masked
+func (c *Config) Redacted() *Config {
+	cfg := *c
+	cfg.Kafka.Brokers = append([]string{}, c.Kafka.Brokers...)
+	cfg.Http.TLS.CipherSuites = append([]string{}, c.Http.TLS.CipherSuites...)
+	cfg.Server.TLS.CipherSuites = append([]string{}, c.Server.TLS.CipherSuites...)
+	redact(reflect.ValueOf(&cfg).Elem())
+	return &cfg
+}
+
+func redact(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct:
+			redact(field)
+		case v.Type().Field(i).Tag.Get("secret") == "true" && field.Kind() == reflect.String && field.String() != "":
+			field.SetString(redacted)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/statistics"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+)
+
+const (
+	dateLayout   = "2006-01-02"
+	defaultLimit = 10
+	maxLimit     = 100
+	// defaultDays period length when from is omitted
+	defaultDays = 30
+	// maxDays longest period that can be requested
+	maxDays = 366
+)
+
+type handlers struct {
+	repo statistics.StatisticsRepository
+}
+
+// statisticsFilter parse from, to (inclusive dates) and limit query params.
+// to defaults to the UTC day of now, from to defaultDays before to, the period is capped to maxDays
+func statisticsFilter(c echo.Context, now time.Time) (*models.StatisticsFilter, error) {
+	filter := &models.StatisticsFilter{Limit: defaultLimit}
+
+	if from := c.QueryParam("from"); from != "" {
+		t, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return nil, errors.New("invalid from, expected YYYY-MM-DD")
+		}
+		filter.From = &t
+	}
+	if to := c.QueryParam("to"); to != "" {
+		t, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return nil, errors.New("invalid to, expected YYYY-MM-DD")
+		}
+		t = t.AddDate(0, 0, 1)
+		filter.To = &t
+	}
+	if filter.To == nil {
+		now = now.UTC()
+		t := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		filter.To = &t
+	}
+	if filter.From == nil {
+		t := filter.To.AddDate(0, 0, -defaultDays)
+		filter.From = &t
+	}
+	if !filter.From.Before(*filter.To) {
+		return nil, errors.New("from must not be after to")
+	}
+	if filter.To.After(filter.From.AddDate(0, 0, maxDays)) {
+		return nil, errors.New("period must not be longer than " + strconv.Itoa(maxDays) + " days")
+	}
+
+	if limit := c.QueryParam("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 || l > maxLimit {
+			return nil, errors.New("invalid limit")
+		}
+		filter.Limit = l
+	}
+
+	return filter, nil
+}
+
+// getSales godoc
+//
+//	@Summary		Sales Statistics
+//	@Tags			Statistics
+//	@Description	Sales count and revenue per day and the average basket of a user per day
+//	@ID				get-sales-statistics
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query	string	false	"First day, YYYY-MM-DD, 30 days before to by default"
+//	@Param			to		query	string	false	"Last day, YYYY-MM-DD, today by default, at most 366 days after from"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/statistics/sales [get]
+func (h *handlers) getSales(c echo.Context) error {
+	filter, err := statisticsFilter(c, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+
+	stats, err := h.repo.Sales(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": stats,
+	})
+}
+
+// getUsers godoc
+//
+//	@Summary		Top Buyers
+//	@Tags			Statistics
+//	@Description	Users who spent the most
+//	@ID				get-users-statistics
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query	string	false	"First day, YYYY-MM-DD, 30 days before to by default"
+//	@Param			to		query	string	false	"Last day, YYYY-MM-DD, today by default, at most 366 days after from"
+//	@Param			limit	query	int		false	"Number of buyers"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/statistics/users [get]
+func (h *handlers) getUsers(c echo.Context) error {
+	filter, err := statisticsFilter(c, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+
+	buyers, err := h.repo.TopBuyers(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": buyers,
+	})
+}
+
+// getProducts godoc
+//
+//	@Summary		Products Statistics
+//	@Tags			Statistics
+//	@Description	Units sold and revenue per product
+//	@ID				get-products-statistics
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query	string	false	"First day, YYYY-MM-DD, 30 days before to by default"
+//	@Param			to		query	string	false	"Last day, YYYY-MM-DD, today by default, at most 366 days after from"
+//	@Param			limit	query	int		false	"Number of products"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/statistics/products [get]
+func (h *handlers) getProducts(c echo.Context) error {
+	filter, err := statisticsFilter(c, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+
+	products, err := h.repo.Products(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": products,
+	})
+}
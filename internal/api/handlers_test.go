@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+)
+
+func date(value string) time.Time {
+	t, err := time.Parse(dateLayout, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestStatisticsFilter(t *testing.T) {
+	now := time.Date(2024, 3, 15, 22, 30, 0, 0, time.FixedZone("UTC-3", -3*60*60))
+
+	tests := []struct {
+		name      string
+		query     string
+		wantFrom  time.Time
+		wantTo    time.Time
+		wantLimit int
+		wantErr   bool
+	}{
+		{
+			name:      "defaults",
+			wantFrom:  date("2024-02-16"),
+			wantTo:    date("2024-03-17"),
+			wantLimit: defaultLimit,
+		},
+		{
+			name:      "from only",
+			query:     "from=2024-03-01",
+			wantFrom:  date("2024-03-01"),
+			wantTo:    date("2024-03-17"),
+			wantLimit: defaultLimit,
+		},
+		{
+			name:      "to only",
+			query:     "to=2024-01-31",
+			wantFrom:  date("2024-01-02"),
+			wantTo:    date("2024-02-01"),
+			wantLimit: defaultLimit,
+		},
+		{
+			name:      "one day",
+			query:     "from=2024-03-01&to=2024-03-01&limit=5",
+			wantFrom:  date("2024-03-01"),
+			wantTo:    date("2024-03-02"),
+			wantLimit: 5,
+		},
+		{
+			name:      "longest period",
+			query:     "from=2024-01-01&to=2024-12-31",
+			wantFrom:  date("2024-01-01"),
+			wantTo:    date("2025-01-01"),
+			wantLimit: defaultLimit,
+		},
+		{name: "too long", query: "from=2023-01-01&to=2024-12-31", wantErr: true},
+		{name: "from far in the past", query: "from=2000-01-01", wantErr: true},
+		{name: "from after to", query: "from=2024-03-02&to=2024-03-01", wantErr: true},
+		{name: "from after default to", query: "from=2024-04-01", wantErr: true},
+		{name: "invalid from", query: "from=01.03.2024", wantErr: true},
+		{name: "invalid to", query: "to=tomorrow", wantErr: true},
+		{name: "zero limit", query: "limit=0", wantErr: true},
+		{name: "limit too big", query: "limit=101", wantErr: true},
+	}
+
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/statistics/sales?"+tt.query, nil)
+			c := e.NewContext(req, httptest.NewRecorder())
+
+			filter, err := statisticsFilter(c, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("statisticsFilter() = %+v, want error", filter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("statisticsFilter() error = %v", err)
+			}
+			if !filter.From.Equal(tt.wantFrom) || !filter.To.Equal(tt.wantTo) {
+				t.Errorf("period = [%s, %s), want [%s, %s)", filter.From, filter.To, tt.wantFrom, tt.wantTo)
+			}
+			if filter.Limit != tt.wantLimit {
+				t.Errorf("Limit = %d, want %d", filter.Limit, tt.wantLimit)
+			}
+		})
+	}
+}
+
+type fakeRepo struct {
+	filter *models.StatisticsFilter
+}
+
+func (r *fakeRepo) Sales(ctx context.Context, filter *models.StatisticsFilter) (*models.SalesStatistics, error) {
+	r.filter = filter
+	return &models.SalesStatistics{Daily: []models.DailySales{}}, nil
+}
+
+func (r *fakeRepo) TopBuyers(ctx context.Context, filter *models.StatisticsFilter) ([]models.BuyerStatistics, error) {
+	r.filter = filter
+	return []models.BuyerStatistics{}, nil
+}
+
+func (r *fakeRepo) Products(ctx context.Context, filter *models.StatisticsFilter) ([]models.ProductStatistics, error) {
+	r.filter = filter
+	return []models.ProductStatistics{}, nil
+}
+
+func TestRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+	}{
+		{name: "sales", target: "/api/statistics/sales", wantStatus: http.StatusOK},
+		{name: "users", target: "/api/statistics/users?limit=3", wantStatus: http.StatusOK},
+		{name: "products", target: "/api/statistics/products?from=2024-01-01&to=2024-01-31", wantStatus: http.StatusOK},
+		{name: "sales period too long", target: "/api/statistics/sales?from=2000-01-01&to=2024-01-01", wantStatus: http.StatusBadRequest},
+		{name: "users invalid limit", target: "/api/statistics/users?limit=x", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			repo := &fakeRepo{}
+			NewRouter(e.Group("/api"), repo)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if repo.filter != nil {
+					t.Errorf("repository called with %+v on a bad request", repo.filter)
+				}
+				return
+			}
+			if repo.filter == nil || repo.filter.From == nil || repo.filter.To == nil {
+				t.Fatalf("repository filter = %+v, want a bounded period", repo.filter)
+			}
+		})
+	}
+}
@@ -2,12 +2,14 @@ package api
 
 import (
 	_ "github.com/Lidne/praktika_MAI/docs"
+	"github.com/Lidne/praktika_MAI/internal/statistics"
 	"github.com/labstack/echo/v4"
 )
 
-func NewRouter(e *echo.Group) {
-	statisticsGroup := e.Group("/statistics")
-	statisticsGroup.GET("/sales", getSales)
-	statisticsGroup.GET("/users", getUsers)
-	statisticsGroup.GET("/products", getProducts)
+func NewRouter(e *echo.Group, repo statistics.StatisticsRepository, m ...echo.MiddlewareFunc) {
+	h := &handlers{repo: repo}
+	statisticsGroup := e.Group("/statistics", m...)
+	statisticsGroup.GET("/sales", h.getSales)
+	statisticsGroup.GET("/users", h.getUsers)
+	statisticsGroup.GET("/products", h.getProducts)
 }
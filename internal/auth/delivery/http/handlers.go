@@ -0,0 +1,204 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/session"
+	"github.com/Lidne/praktika_MAI/internal/user"
+	"github.com/Lidne/praktika_MAI/pkg/csrf"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+)
+
+type handlers struct {
+	users    user.UserRepository
+	sessions session.SessionRepository
+	validate *validator.Validate
+	cfg      *config.Config
+}
+
+type loginRequest struct {
+	Login    string `json:"login" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+type loginResponse struct {
+	AccessToken string              `json:"access_token"`
+	TokenType   string              `json:"token_type"`
+	ExpiresAt   time.Time           `json:"expires_at"`
+	User        models.UserResponse `json:"user"`
+}
+
+// login godoc
+//
+//	@Summary		Login
+//	@Tags			Auth
+//	@Description	Check login and password and issue a signed access token
+//	@ID				login
+//	@Accept			json
+//	@Produce		json
+//	@Param			credentials	body	loginRequest	true	"Credentials"
+//	@Success		200	{object}	loginResponse
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		401	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Router			/api/auth/login [post]
+func (h *handlers) login(c echo.Context) error {
+	var req loginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if err := h.validate.StructCtx(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	usr, err := h.checkCredentials(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	token, expiresAt, err := auth.GenerateToken(usr, h.cfg.Http.JwtSecretKey, h.cfg.Http.JwtExpire*time.Second)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, loginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+		User:        usr.Response(),
+	})
+}
+
+// dummyPasswordHash bcrypt hash with the default cost compared on unknown logins, so that they take
+// as long as a wrong password
+var dummyPasswordHash = []byte("$2a$10$liWBTeyWFf4xp.R6UFVpFeN8ITgWfXrZ7..nMTeQk07z23FenWnr2")
+
+// checkCredentials user with the login and password, unknown login and wrong password are indistinguishable
+// by the response and by its timing
+func (h *handlers) checkCredentials(ctx context.Context, req *loginRequest) (*models.User, error) {
+	usr, err := h.users.GetByLogin(ctx, req.Login)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+			return nil, httpErrors.WrongCredentials
+		}
+		return nil, err
+	}
+	if err := usr.ComparePasswords(req.Password); err != nil {
+		return nil, httpErrors.WrongCredentials
+	}
+	return usr, nil
+}
+
+// sessionLogin godoc
+//
+//	@Summary		Session Login
+//	@Tags			Auth
+//	@Description	Check login and password and start a server-side session, the session token is set as an HttpOnly cookie
+//	@ID				session-login
+//	@Accept			json
+//	@Produce		json
+//	@Param			credentials	body	loginRequest	true	"Credentials"
+//	@Success		201	{object}	models.SessionResponse
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		401	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Router			/api/auth/session [post]
+func (h *handlers) sessionLogin(c echo.Context) error {
+	var req loginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if err := h.validate.StructCtx(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	usr, err := h.checkCredentials(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	token, id, err := session.NewToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	sess := &models.Session{
+		ID:         id,
+		UserID:     usr.ID,
+		Login:      usr.Login,
+		Role:       string(auth.UserRole(usr)),
+		UserAgent:  c.Request().UserAgent(),
+		IP:         c.RealIP(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err := h.sessions.Create(c.Request().Context(), sess, auth.SessionTTL(h.cfg)); err != nil {
+		return err
+	}
+	c.SetCookie(auth.SessionCookie(h.cfg, token))
+	return c.JSON(http.StatusCreated, echo.Map{
+		"data": models.SessionResponse{Session: *sess, Current: true},
+	})
+}
+
+// sessionLogout godoc
+//
+//	@Summary		Session Logout
+//	@Tags			Auth
+//	@Description	End the session of the cookie and remove the cookie
+//	@ID				session-logout
+//	@Success		204
+//	@Failure		401	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Router			/api/auth/session [delete]
+func (h *handlers) sessionLogout(c echo.Context) error {
+	sess, ok := auth.GetSession(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, httpErrors.NewUnauthorizedError(httpErrors.NoCookie.Error()))
+	}
+	if err := h.sessions.Delete(c.Request().Context(), sess); err != nil {
+		return err
+	}
+	c.SetCookie(auth.ExpiredSessionCookie(h.cfg))
+	return c.NoContent(http.StatusNoContent)
+}
+
+type csrfResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// getCSRFToken godoc
+//
+//	@Summary		Get CSRF Token
+//	@Tags			Auth
+//	@Description	Issue a CSRF token bound to the session of the cookie, it must be sent as X-CSRF-Token header with every POST, PUT, PATCH and DELETE request authenticated by the cookie
+//	@ID				get-csrf-token
+//	@Produce		json
+//	@Success		200	{object}	csrfResponse
+//	@Header			200	{string}	X-CSRF-Token	"CSRF token"
+//	@Failure		401	{object}	httpErrors.RestError
+//	@Router			/api/auth/csrf [get]
+func (h *handlers) getCSRFToken(c echo.Context) error {
+	sess, ok := auth.GetSession(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, httpErrors.NewUnauthorizedError(httpErrors.NoCookie.Error()))
+	}
+	expiresAt := time.Now().Add(h.cfg.Http.CsrfExpire * time.Second).UTC()
+	token := csrf.MakeToken(h.cfg.Http.CsrfSecretKey, sess.ID, expiresAt)
+	c.Response().Header().Set(echo.HeaderXCSRFToken, token)
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": csrfResponse{Token: token, ExpiresAt: expiresAt},
+	})
+}
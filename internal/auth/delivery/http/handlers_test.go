@@ -0,0 +1,176 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/user"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Errorf(template string, args ...interface{}) {}
+
+// fakeUsers user repository finding users by login
+type fakeUsers struct {
+	user.UserRepository
+	users map[string]models.User
+}
+
+func (r *fakeUsers) GetByLogin(ctx context.Context, login string) (*models.User, error) {
+	usr, ok := r.users[login]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &usr, nil
+}
+
+// fakeSessions session repository keeping created sessions
+type fakeSessions struct {
+	created []models.Session
+}
+
+func (r *fakeSessions) Create(ctx context.Context, session *models.Session, ttl time.Duration) error {
+	r.created = append(r.created, *session)
+	return nil
+}
+
+func (r *fakeSessions) Touch(ctx context.Context, id string, ttl time.Duration) (*models.Session, error) {
+	return nil, nil
+}
+
+func (r *fakeSessions) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	return nil, nil
+}
+
+func (r *fakeSessions) ListByUser(ctx context.Context, userID int) ([]models.Session, error) {
+	return nil, nil
+}
+
+func (r *fakeSessions) Delete(ctx context.Context, session *models.Session) error {
+	return nil
+}
+
+func (r *fakeSessions) DeleteByUser(ctx context.Context, userID int) error {
+	return nil
+}
+
+func newServer(t *testing.T, sessions *fakeSessions) *echo.Echo {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret-password"), bcrypt.DefaultCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := &fakeUsers{users: map[string]models.User{
+		"bob": {ID: 2, Name: "Bob", Login: "bob", Password: string(hash)},
+	}}
+	cfg := &config.Config{Http: config.Http{JwtSecretKey: "secret", JwtExpire: 60, SessionCookieName: "session", CookieLifeTime: 60}}
+
+	e := echo.New()
+	e.HTTPErrorHandler = httpErrors.NewHTTPErrorHandler(nopLogger{})
+	NewRouter(e.Group("/api"), e.Group("/api"), users, sessions, validator.New(), cfg)
+	return e
+}
+
+func postLogin(e *echo.Echo, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLogin(t *testing.T) {
+	e := newServer(t, &fakeSessions{})
+
+	rec := postLogin(e, "/api/auth/login", `{"login": "bob", "password": "secret-password"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	var res loginResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	claims, err := auth.ParseToken(res.AccessToken, "secret")
+	if err != nil {
+		t.Fatalf("ParseToken() error = %v", err)
+	}
+	if claims.ID != 2 || claims.Role != auth.RoleUser {
+		t.Errorf("claims = %+v, want user 2 with the user role", claims)
+	}
+	if res.User.Login != "bob" || strings.Contains(rec.Body.String(), `"password"`) {
+		t.Errorf("user = %s", rec.Body)
+	}
+}
+
+func TestLoginWrongCredentials(t *testing.T) {
+	e := newServer(t, &fakeSessions{})
+
+	wrongStart := time.Now()
+	wrong := postLogin(e, "/api/auth/login", `{"login": "bob", "password": "wrong-password"}`)
+	wrongElapsed := time.Since(wrongStart)
+
+	unknownStart := time.Now()
+	unknown := postLogin(e, "/api/auth/login", `{"login": "nobody", "password": "wrong-password"}`)
+	unknownElapsed := time.Since(unknownStart)
+
+	for name, rec := range map[string]*httptest.ResponseRecorder{"wrong password": wrong, "unknown login": unknown} {
+		if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), httpErrors.ErrWrongCredentials) {
+			t.Errorf("%s: status = %d, body = %s, want 401 %s", name, rec.Code, rec.Body, httpErrors.ErrWrongCredentials)
+		}
+	}
+	if wrong.Body.String() != unknown.Body.String() {
+		t.Errorf("bodies differ: %s and %s", wrong.Body, unknown.Body)
+	}
+	// without the dummy compare an unknown login answers orders of magnitude faster
+	if unknownElapsed < wrongElapsed/4 {
+		t.Errorf("unknown login took %v, wrong password %v", unknownElapsed, wrongElapsed)
+	}
+}
+
+func TestDummyPasswordHash(t *testing.T) {
+	cost, err := bcrypt.Cost(dummyPasswordHash)
+	if err != nil {
+		t.Fatalf("Cost() error = %v", err)
+	}
+	if cost != bcrypt.DefaultCost {
+		t.Errorf("Cost() = %d, want %d", cost, bcrypt.DefaultCost)
+	}
+}
+
+func TestSessionLogin(t *testing.T) {
+	sessions := &fakeSessions{}
+	e := newServer(t, sessions)
+
+	if rec := postLogin(e, "/api/auth/session", `{"login": "nobody", "password": "secret-password"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown login: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	rec := postLogin(e, "/api/auth/session", `{"login": "bob", "password": "secret-password"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+	if len(sessions.created) != 1 || sessions.created[0].UserID != 2 || sessions.created[0].Role != string(auth.RoleUser) {
+		t.Fatalf("created sessions = %+v", sessions.created)
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != "session" || !cookies[0].HttpOnly || cookies[0].Value == "" {
+		t.Errorf("cookies = %+v, want an HttpOnly session cookie", cookies)
+	}
+}
@@ -0,0 +1,19 @@
+package http
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/session"
+	"github.com/Lidne/praktika_MAI/internal/user"
+)
+
+// NewRouter register login routes on public and the routes of an authenticated session on api
+func NewRouter(public, api *echo.Group, users user.UserRepository, sessions session.SessionRepository, validate *validator.Validate, cfg *config.Config) {
+	h := &handlers{users: users, sessions: sessions, validate: validate, cfg: cfg}
+	public.POST("/auth/login", h.login)
+	public.POST("/auth/session", h.sessionLogin)
+	api.GET("/auth/csrf", h.getCSRFToken)
+	api.DELETE("/auth/session", h.sessionLogout)
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+)
+
+const claimsCtxKey = "auth.claims"
+
+// Claims access token payload
+type Claims struct {
+	ID    int    `json:"id"`
+	Login string `json:"login"`
+	Role  Role   `json:"role"`
+	jwt.StandardClaims
+}
+
+// GenerateToken sign access token of the user, valid for ttl
+func GenerateToken(user *models.User, secret string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := &Claims{
+		ID:    user.ID,
+		Login: user.Login,
+		Role:  UserRole(user),
+		StandardClaims: jwt.StandardClaims{
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "jwt.SignedString")
+	}
+	return token, expiresAt, nil
+}
+
+// ParseToken verify signature and expiry of the access token
+func ParseToken(tokenString string, secret string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.Wrapf(httpErrors.InvalidJWTToken, "unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(httpErrors.InvalidJWTToken, err.Error())
+	}
+	if !token.Valid || claims.ID == 0 {
+		return nil, httpErrors.InvalidJWTClaims
+	}
+	return claims, nil
+}
+
+// SetClaims store claims of the authenticated user in the request context
+func SetClaims(c echo.Context, claims *Claims) {
+	c.Set(claimsCtxKey, claims)
+}
+
+// GetClaims claims of the authenticated user, false for anonymous requests
+func GetClaims(c echo.Context) (*Claims, bool) {
+	claims, ok := c.Get(claimsCtxKey).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+)
+
+const testSecret = "jwt-test-secret-of-at-least-32-bytes"
+
+func TestParseToken(t *testing.T) {
+	admin := &models.User{ID: 7, Login: "admin", IsAdmin: true}
+	valid, _, err := GenerateToken(admin, testSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+	expired, _, err := GenerateToken(admin, testSecret, -time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateToken() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		secret string
+		want   error
+	}{
+		{name: "valid", token: valid, secret: testSecret},
+		{name: "wrong secret", token: valid, secret: "another-secret", want: httpErrors.InvalidJWTToken},
+		{name: "expired", token: expired, secret: testSecret, want: httpErrors.InvalidJWTToken},
+		{name: "malformed", token: "not.a.token", secret: testSecret, want: httpErrors.InvalidJWTToken},
+		{name: "empty", token: "", secret: testSecret, want: httpErrors.InvalidJWTToken},
+		{
+			name:   "none algorithm",
+			token:  signed(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, &Claims{ID: 7, Role: RoleAdmin}),
+			secret: testSecret,
+			want:   httpErrors.InvalidJWTToken,
+		},
+		{
+			name:   "no user id",
+			token:  signed(t, jwt.SigningMethodHS256, []byte(testSecret), &Claims{Login: "ghost", Role: RoleAdmin}),
+			secret: testSecret,
+			want:   httpErrors.InvalidJWTClaims,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseToken(tt.token, tt.secret)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("ParseToken() error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				return
+			}
+			if claims.ID != admin.ID || claims.Login != admin.Login || claims.Role != RoleAdmin {
+				t.Errorf("ParseToken() claims = %+v, want id %d, login %s, role %s", claims, admin.ID, admin.Login, RoleAdmin)
+			}
+			if claims.Subject != "7" {
+				t.Errorf("Subject = %q, want %q", claims.Subject, "7")
+			}
+		})
+	}
+}
+
+func signed(t *testing.T, method jwt.SigningMethod, key interface{}, claims *Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+	return token
+}
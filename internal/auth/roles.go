@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+)
+
+// Role of the authenticated user, carried in the access token
+type Role string
+
+const (
+	RoleAdmin Role = "admin"
+	RoleUser  Role = "user"
+)
+
+// Permission action that can be granted to a role
+type Permission string
+
+const (
+	// PermCatalogWrite create, update and delete products
+	PermCatalogWrite Permission = "catalog:write"
+	// PermUsersRead read any user, not only the own profile
+	PermUsersRead Permission = "users:read"
+	// PermUsersWrite create, update and delete any user and change roles
+	PermUsersWrite Permission = "users:write"
+	// PermSalesRead read any sale and sales reports, not only the own purchases
+	PermSalesRead Permission = "sales:read"
+	// PermSalesWrite register a sale on behalf of any user
+	PermSalesWrite Permission = "sales:write"
+	// PermSystemWrite change runtime settings of the service such as the log level
+	PermSystemWrite Permission = "system:write"
+)
+
+// rolePermissions permissions granted to each role, a new role is a new entry here
+var rolePermissions = map[Role]map[Permission]bool{
+	RoleAdmin: {
+		PermCatalogWrite: true,
+		PermUsersRead:    true,
+		PermUsersWrite:   true,
+		PermSalesRead:    true,
+		PermSalesWrite:   true,
+		PermSystemWrite:  true,
+	},
+	RoleUser: {},
+}
+
+// UserRole role of the user
+func UserRole(user *models.User) Role {
+	if user.IsAdmin {
+		return RoleAdmin
+	}
+	return RoleUser
+}
+
+// Can check that the role is granted the permission
+func (r Role) Can(perm Permission) bool {
+	return rolePermissions[r][perm]
+}
+
+// Allowed check that the authenticated user is granted the permission
+func Allowed(c echo.Context, perm Permission) bool {
+	claims, ok := GetClaims(c)
+	return ok && claims.Role.Can(perm)
+}
+
+// CanAccess check that the authenticated user owns the resource or is granted the permission
+func CanAccess(c echo.Context, perm Permission, ownerID int) bool {
+	claims, ok := GetClaims(c)
+	return ok && (claims.ID == ownerID || claims.Role.Can(perm))
+}
+
+// UserID id of the authenticated user, 0 for anonymous requests
+func UserID(c echo.Context) int {
+	if claims, ok := GetClaims(c); ok {
+		return claims.ID
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/models"
+)
+
+const sessionCtxKey = "auth.session"
+
+// SessionTTL idle lifetime of a session, every authenticated request extends it
+func SessionTTL(cfg *config.Config) time.Duration {
+	return time.Duration(cfg.Http.CookieLifeTime) * time.Second
+}
+
+// SessionCookie HttpOnly cookie carrying the session token
+func SessionCookie(cfg *config.Config, token string) *http.Cookie {
+	return &http.Cookie{
+		Name:     cfg.Http.SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   cfg.Http.CookieLifeTime,
+		HttpOnly: true,
+		Secure:   !cfg.Server.Development,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// ExpiredSessionCookie cookie that removes the session cookie from the browser
+func ExpiredSessionCookie(cfg *config.Config) *http.Cookie {
+	cookie := SessionCookie(cfg, "")
+	cookie.MaxAge = -1
+	return cookie
+}
+
+// SessionClaims claims of the session owner
+func SessionClaims(session *models.Session) *Claims {
+	return &Claims{ID: session.UserID, Login: session.Login, Role: Role(session.Role)}
+}
+
+// SetSession store the session the request is authenticated with
+func SetSession(c echo.Context, session *models.Session) {
+	c.Set(sessionCtxKey, session)
+}
+
+// GetSession session the request is authenticated with, false for bearer tokens
+func GetSession(c echo.Context) (*models.Session, bool) {
+	session, ok := c.Get(sessionCtxKey).(*models.Session)
+	return session, ok
+}
@@ -0,0 +1,103 @@
+package middlewares
+
+import (
+	"context"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+// metadataCarrier reads trace headers from incoming gRPC metadata
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) ForeachKey(handler func(key, val string) error) error {
+	for key, values := range c {
+		for _, value := range values {
+			if err := handler(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GrpcTracing unary interceptor continuing the trace of the incoming metadata or starting a new one,
+// the span is passed to the handler through ctx. Must run before GrpcAuth to trace its lookups
+func (m *middlewareManager) GrpcTracing(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	tracer := opentracing.GlobalTracer()
+	md, _ := metadata.FromIncomingContext(ctx)
+	spanCtx, _ := tracer.Extract(opentracing.TextMap, metadataCarrier(md))
+
+	span := tracer.StartSpan(info.FullMethod, ext.RPCServerOption(spanCtx))
+	defer span.Finish()
+	ext.Component.Set(span, "gRPC")
+
+	resp, err := handler(opentracing.ContextWithSpan(ctx, span), req)
+	code := status.Code(err)
+	span.SetTag("grpc.code", code.String())
+	if err != nil {
+		ext.Error.Set(span, isServerError(code))
+		span.LogFields(log.Error(err))
+	}
+	return resp, err
+}
+
+// isServerError whether the status code reports a failure of the server rather than of the request
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// GrpcAuth unary interceptor requiring an "authorization: Bearer <token>" metadata and the permission
+// on the listed full method names, other methods stay public
+func (m *middlewareManager) GrpcAuth(methods map[string]auth.Permission) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		perm, ok := methods[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		claims, err := m.grpcClaims(ctx)
+		if err != nil {
+			logger.FromContext(ctx, m.log).Debugf("GrpcAuth %s: %v", info.FullMethod, err)
+			return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+		}
+		if err := m.currentRole(ctx, claims); err != nil {
+			if errors.Is(err, ErrUserDeleted) {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			m.log.Errorf("GrpcAuth %s: %v", info.FullMethod, err)
+			return nil, status.Error(codes.Internal, "Internal Server Error")
+		}
+		if !claims.Role.Can(perm) {
+			return nil, status.Error(codes.PermissionDenied, "Permission Denied")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (m *middlewareManager) grpcClaims(ctx context.Context) (*auth.Claims, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, errors.New("no authorization metadata")
+	}
+	tokenString, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || tokenString == "" {
+		return nil, errors.New("authorization is not a bearer token")
+	}
+	return auth.ParseToken(tokenString, m.cfg.Http.JwtSecretKey)
+}
@@ -0,0 +1,86 @@
+package middlewares
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Lidne/praktika_MAI/config"
+)
+
+func TestGrpcTracing(t *testing.T) {
+	tests := []struct {
+		name       string
+		withParent bool
+		handlerErr error
+		wantCode   string
+		wantError  interface{}
+	}{
+		{name: "new trace", wantCode: "OK"},
+		{name: "continued trace", withParent: true, wantCode: "OK"},
+		{name: "client error", handlerErr: status.Error(codes.NotFound, "not found"), wantCode: "NotFound", wantError: false},
+		{name: "server error", handlerErr: status.Error(codes.Internal, "internal"), wantCode: "Internal", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := mocktracer.New()
+			global := opentracing.GlobalTracer()
+			opentracing.SetGlobalTracer(tracer)
+			defer opentracing.SetGlobalTracer(global)
+
+			ctx := context.Background()
+			var parent *mocktracer.MockSpan
+			if tt.withParent {
+				parent = tracer.StartSpan("client").(*mocktracer.MockSpan)
+				carrier := opentracing.TextMapCarrier{}
+				if err := tracer.Inject(parent.Context(), opentracing.TextMap, carrier); err != nil {
+					t.Fatalf("Inject() error = %v", err)
+				}
+				ctx = metadata.NewIncomingContext(ctx, metadata.New(carrier))
+			}
+
+			mw := NewMiddlewareManager(nil, &config.Config{}, nil, nil)
+			info := &grpc.UnaryServerInfo{FullMethod: "/productsService.ProductsService/GetByID"}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				if opentracing.SpanFromContext(ctx) == nil {
+					t.Error("handler context has no span")
+				}
+				return "response", tt.handlerErr
+			}
+
+			resp, err := mw.GrpcTracing(ctx, "request", info, handler)
+			if err != tt.handlerErr || (err == nil && resp != "response") {
+				t.Fatalf("GrpcTracing() = %v, %v, want the handler result", resp, err)
+			}
+
+			spans := tracer.FinishedSpans()
+			if len(spans) != 1 {
+				t.Fatalf("finished %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.OperationName != info.FullMethod {
+				t.Errorf("operation = %s, want %s", span.OperationName, info.FullMethod)
+			}
+			if tt.withParent {
+				if span.SpanContext.TraceID != parent.SpanContext.TraceID || span.ParentID != parent.SpanContext.SpanID {
+					t.Error("span does not continue the trace of the metadata")
+				}
+			} else if span.ParentID != 0 {
+				t.Errorf("span has parent %d, want a new trace", span.ParentID)
+			}
+			if got := span.Tag("grpc.code"); got != tt.wantCode {
+				t.Errorf("grpc.code = %v, want %s", got, tt.wantCode)
+			}
+			if got := span.Tag("error"); got != tt.wantError {
+				t.Errorf("error tag = %v, want %v", got, tt.wantError)
+			}
+		})
+	}
+}
@@ -1,41 +1,291 @@
 package middlewares
 
 import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/labstack/echo/v4"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
 
 	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/session"
+	"github.com/Lidne/praktika_MAI/internal/user"
+	"github.com/Lidne/praktika_MAI/pkg/csrf"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	"github.com/Lidne/praktika_MAI/pkg/jaeger"
 	"github.com/Lidne/praktika_MAI/pkg/logger"
 )
 
+const unmatchedRoute = "unmatched"
+
+// ErrUserDeleted the token or session belongs to a user that no longer exists
+var ErrUserDeleted = errors.New("user no longer exists")
+
 var (
-	httpTotalRequests = promauto.NewCounter(prometheus.CounterOpts{
-		Name: "http_microservice_total_requests",
-		Help: "The total number of incoming HTTP requests",
-	})
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "The total number of handled HTTP requests",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "The HTTP request latency",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	httpRequestsInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "The number of HTTP requests being served",
+	}, []string{"method", "route"})
 )
 
 // MiddlewareManager http middlewares
 type middlewareManager struct {
-	log logger.Logger
-	cfg *config.Config
+	log      logger.Logger
+	cfg      *config.Config
+	sessions session.SessionRepository
+	users    user.UserRepository
 }
 
 // MiddlewareManager interface
 type MiddlewareManager interface {
 	Metrics(next echo.HandlerFunc) echo.HandlerFunc
+	AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc
+	RequirePermission(perm auth.Permission) echo.MiddlewareFunc
+	CSRFMiddleware(next echo.HandlerFunc) echo.HandlerFunc
+	Tracing(next echo.HandlerFunc) echo.HandlerFunc
+	RequestLogger(next echo.HandlerFunc) echo.HandlerFunc
+	GrpcTracing(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+	GrpcAuth(methods map[string]auth.Permission) grpc.UnaryServerInterceptor
 }
 
 // NewMiddlewareManager constructor
-func NewMiddlewareManager(log logger.Logger, cfg *config.Config) *middlewareManager {
-	return &middlewareManager{log: log, cfg: cfg}
+func NewMiddlewareManager(log logger.Logger, cfg *config.Config, sessions session.SessionRepository, users user.UserRepository) *middlewareManager {
+	return &middlewareManager{log: log, cfg: cfg, sessions: sessions, users: users}
 }
 
-// Metrics prometheus metrics
+// Metrics prometheus request count, latency and in-flight requests labelled by route template, method and status.
+// It is the outermost middleware seeing handler errors and the only one writing them, the error is handled
+// once it returns
 func (m *middlewareManager) Metrics(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		httpTotalRequests.Inc()
+		method := c.Request().Method
+		route := c.Path()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		inFlight := httpRequestsInFlight.WithLabelValues(method, route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		status := strconv.Itoa(c.Response().Status)
+		httpRequestsTotal.WithLabelValues(method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+		return nil
+	}
+}
+
+// responseStatus status of the response, or the status err will be written with by Metrics
+func responseStatus(c echo.Context, err error) int {
+	if err != nil && !c.Response().Committed {
+		return httpErrors.ParseErrors(err).Status()
+	}
+	return c.Response().Status
+}
+
+// Tracing continue the trace of the incoming request headers or start a new one, the span is passed
+// to handlers through the request context. Errors are recorded on the span and returned to Metrics
+func (m *middlewareManager) Tracing(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		tracer := opentracing.GlobalTracer()
+		spanCtx, _ := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+
+		route := c.Path()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		span := tracer.StartSpan(req.Method+" "+route, ext.RPCServerOption(spanCtx))
+		defer span.Finish()
+		ext.HTTPMethod.Set(span, req.Method)
+		ext.HTTPUrl.Set(span, req.URL.String())
+		ext.Component.Set(span, "echo")
+
+		c.SetRequest(req.WithContext(opentracing.ContextWithSpan(req.Context(), span)))
+		err := next(c)
+
+		status := responseStatus(c, err)
+		ext.HTTPStatusCode.Set(span, uint16(status))
+		if err != nil {
+			ext.Error.Set(span, status >= http.StatusInternalServerError)
+			span.LogFields(log.Error(err))
+		}
+		return err
+	}
+}
+
+// RequestLogger put a logger with the request id, trace id and route into the request context and
+// write an access log entry once the request is served. Must run after Tracing to see the span
+func (m *middlewareManager) RequestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		route := c.Path()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		fields := []interface{}{
+			"request_id", c.Response().Header().Get(echo.HeaderXRequestID),
+			"route", route,
+		}
+		if span := opentracing.SpanFromContext(req.Context()); span != nil {
+			if traceID := jaeger.TraceID(span); traceID != "" {
+				fields = append(fields, "trace_id", traceID)
+			}
+		}
+		c.SetRequest(req.WithContext(logger.NewContext(req.Context(), m.log.With(fields...))))
+
+		start := time.Now()
+		err := next(c)
+
+		// the context now may carry the user id added by AuthMiddleware
+		logger.FromContext(c.Request().Context(), m.log).Infof(
+			"%s %s %d %s",
+			req.Method,
+			req.URL.RequestURI(),
+			responseStatus(c, err),
+			time.Since(start),
+		)
+		return err
+	}
+}
+
+// withUser add the id of the authenticated user to the request logger
+func (m *middlewareManager) withUser(c echo.Context, claims *auth.Claims) {
+	ctx := c.Request().Context()
+	log := logger.FromContext(ctx, m.log).With("user_id", claims.ID)
+	c.SetRequest(c.Request().WithContext(logger.NewContext(ctx, log)))
+}
+
+// currentRole replace the role of the token or session with the current role of the user, so that
+// demoted and deleted users lose their rights before the token expires. Users are read through the cache,
+// which is invalidated on every update. Returns ErrUserDeleted when the user no longer exists
+func (m *middlewareManager) currentRole(ctx context.Context, claims *auth.Claims) error {
+	usr, err := m.users.GetByID(ctx, strconv.Itoa(claims.ID))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrUserDeleted
+		}
+		return err
+	}
+	claims.Role = auth.UserRole(usr)
+	return nil
+}
+
+// authorize resolve the current role of the claims, deleted users are unauthorized
+func (m *middlewareManager) authorize(c echo.Context, claims *auth.Claims) error {
+	if err := m.currentRole(c.Request().Context(), claims); err != nil {
+		if errors.Is(err, ErrUserDeleted) {
+			return httpErrors.NewUnauthorizedError(err.Error())
+		}
+		return err
+	}
+	return nil
+}
+
+// AuthMiddleware authenticate requests by an "Authorization: Bearer <token>" header or, without it,
+// by the session cookie; every request authenticated by the cookie extends the session
+func (m *middlewareManager) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if header := c.Request().Header.Get(echo.HeaderAuthorization); header != "" {
+			tokenString, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok || tokenString == "" {
+				return c.JSON(http.StatusUnauthorized, httpErrors.NewUnauthorizedError(httpErrors.Unauthorized.Error()))
+			}
+			claims, err := auth.ParseToken(tokenString, m.cfg.Http.JwtSecretKey)
+			if err != nil {
+				logger.FromContext(c.Request().Context(), m.log).Debugf("AuthMiddleware: %v", err)
+				return err
+			}
+			if err := m.authorize(c, claims); err != nil {
+				return err
+			}
+			auth.SetClaims(c, claims)
+			m.withUser(c, claims)
+			return next(c)
+		}
+
+		cookie, err := c.Cookie(m.cfg.Http.SessionCookieName)
+		if err != nil || cookie.Value == "" {
+			return c.JSON(http.StatusUnauthorized, httpErrors.NewUnauthorizedError(httpErrors.NoCookie.Error()))
+		}
+		sess, err := m.sessions.Touch(c.Request().Context(), session.TokenID(cookie.Value), auth.SessionTTL(m.cfg))
+		if err != nil {
+			if errors.Is(err, session.ErrSessionNotFound) {
+				c.SetCookie(auth.ExpiredSessionCookie(m.cfg))
+				return c.JSON(http.StatusUnauthorized, httpErrors.NewUnauthorizedError(err.Error()))
+			}
+			logger.FromContext(c.Request().Context(), m.log).Errorf("AuthMiddleware: %v", err)
+			return err
+		}
+		c.SetCookie(auth.SessionCookie(m.cfg, cookie.Value))
+		claims := auth.SessionClaims(sess)
+		if err := m.authorize(c, claims); err != nil {
+			return err
+		}
+		auth.SetSession(c, sess)
+		auth.SetClaims(c, claims)
+		m.withUser(c, claims)
+		return next(c)
+	}
+}
+
+// RequirePermission reject requests of users whose role is not granted the permission
+func (m *middlewareManager) RequirePermission(perm auth.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if _, ok := auth.GetClaims(c); !ok {
+				return c.JSON(http.StatusUnauthorized, httpErrors.NewUnauthorizedError(httpErrors.Unauthorized.Error()))
+			}
+			if !auth.Allowed(c, perm) {
+				return c.JSON(http.StatusForbidden, httpErrors.NewForbiddenError(httpErrors.PermissionDenied.Error()))
+			}
+			return next(c)
+		}
+	}
+}
+
+// CSRFMiddleware require a valid X-CSRF-Token header on state-changing requests authenticated by the session cookie,
+// bearer tokens are not sent by browsers on their own and need no CSRF protection
+func (m *middlewareManager) CSRFMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		switch c.Request().Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return next(c)
+		}
+		sess, ok := auth.GetSession(c)
+		if !ok {
+			return next(c)
+		}
+		if err := csrf.ValidateToken(m.cfg.Http.CsrfSecretKey, sess.ID, c.Request().Header.Get(echo.HeaderXCSRFToken)); err != nil {
+			logger.FromContext(c.Request().Context(), m.log).Debugf("CSRFMiddleware: %v", err)
+			return err
+		}
 		return next(c)
 	}
 }
@@ -0,0 +1,153 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+// testLogger records access log statuses and discards everything else
+type testLogger struct {
+	logger.Logger
+	statuses *[]interface{}
+}
+
+func (l testLogger) With(args ...interface{}) logger.Logger      { return l }
+func (l testLogger) Debugf(template string, args ...interface{}) {}
+func (l testLogger) Errorf(template string, args ...interface{}) {}
+func (l testLogger) Infof(template string, args ...interface{}) {
+	*l.statuses = append(*l.statuses, args[2])
+}
+
+func TestErrorsAreWrittenOnce(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    echo.HandlerFunc
+		wantStatus int
+		wantWrites int
+	}{
+		{
+			name:       "no error",
+			handler:    func(c echo.Context) error { return c.NoContent(http.StatusNoContent) },
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "not found",
+			handler:    func(c echo.Context) error { return errors.Wrap(pgx.ErrNoRows, "productRepo.GetByID") },
+			wantStatus: http.StatusNotFound,
+			wantWrites: 1,
+		},
+		{
+			name:       "rest error",
+			handler:    func(c echo.Context) error { return httpErrors.NewUnauthorizedError("expired") },
+			wantStatus: http.StatusUnauthorized,
+			wantWrites: 1,
+		},
+		{
+			name:       "unexpected error",
+			handler:    func(c echo.Context) error { return errors.New("connection refused") },
+			wantStatus: http.StatusInternalServerError,
+			wantWrites: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := []interface{}{}
+			log := testLogger{statuses: &statuses}
+			mw := NewMiddlewareManager(log, &config.Config{}, nil, nil)
+
+			e := echo.New()
+			writes := 0
+			handle := httpErrors.NewHTTPErrorHandler(log)
+			e.HTTPErrorHandler = func(err error, c echo.Context) {
+				writes++
+				handle(err, c)
+			}
+			e.Use(mw.Metrics, mw.Tracing, mw.RequestLogger)
+			e.GET("/test", tt.handler)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if writes != tt.wantWrites {
+				t.Errorf("error handler called %d times, want %d", writes, tt.wantWrites)
+			}
+			if len(statuses) != 1 || statuses[0] != tt.wantStatus {
+				t.Errorf("access log statuses = %v, want [%d]", statuses, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *auth.Claims
+		wantStatus int
+	}{
+		{
+			name:       "anonymous",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "role without the permission",
+			claims:     &auth.Claims{ID: 2, Role: auth.RoleUser},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "unknown role",
+			claims:     &auth.Claims{ID: 3, Role: "guest"},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "admin",
+			claims:     &auth.Claims{ID: 1, Role: auth.RoleAdmin},
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := []interface{}{}
+			mw := NewMiddlewareManager(testLogger{statuses: &statuses}, &config.Config{}, nil, nil)
+
+			e := echo.New()
+			e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if tt.claims != nil {
+						auth.SetClaims(c, tt.claims)
+					}
+					return next(c)
+				}
+			})
+			called := false
+			e.GET("/test", func(c echo.Context) error {
+				called = true
+				return c.NoContent(http.StatusNoContent)
+			}, mw.RequirePermission(auth.PermUsersRead))
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/test", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if called != (tt.wantStatus == http.StatusNoContent) {
+				t.Errorf("handler called = %v", called)
+			}
+		})
+	}
+}
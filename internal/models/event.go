@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent domain event stored in the outbox table until it is published to Kafka
+type OutboxEvent struct {
+	ID          int64
+	Aggregate   string
+	AggregateID string
+	Type        string
+	Payload     json.RawMessage
+	CreatedAt   time.Time
+}
+
+// EventMessage Kafka representation of a domain event
+type EventMessage struct {
+	ID          int64           `json:"id"`
+	Aggregate   string          `json:"aggregate"`
+	AggregateID string          `json:"aggregate_id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
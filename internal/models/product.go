@@ -4,8 +4,31 @@ import "github.com/jackc/pgx/v5/pgtype"
 
 // Product models
 type Product struct {
-	ID        int         `json:"id"`
-	Name      string      `json:"name"`
-	Price     int         `json:"price"`
-	CreatedAt pgtype.Time `json:"created_at"`
+	ID          int              `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Price       int              `json:"price"`
+	CreatedAt   pgtype.Timestamp `json:"created_at"`
+}
+
+// ProductFilter optional conditions for products list
+type ProductFilter struct {
+	MinPrice *int
+	MaxPrice *int
+}
+
+// ProductsList page of products
+type ProductsList struct {
+	Products   []Product
+	NextCursor string
+}
+
+// ProductsSearchList page of products found by full-text search
+type ProductsSearchList struct {
+	TotalCount int64
+	TotalPages int64
+	Page       int64
+	Size       int64
+	HasMore    bool
+	Products   []Product
 }
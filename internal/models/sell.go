@@ -1,10 +1,45 @@
 package models
 
-import "github.com/jackc/pgx/v5/pgtype"
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
 
 type Sell struct {
-	ID        int
-	UserId    int
-	ProductId int
-	UpdatedAt pgtype.Timestamp
+	ID        int `json:"id"`
+	UserId    int `json:"user_id"`
+	ProductId int `json:"product_id"`
+	// Price product price at the moment of the sell
+	Price     int              `json:"price"`
+	UpdatedAt pgtype.Timestamp `json:"updated_at"`
+}
+
+// SellFilter optional conditions for sells list
+type SellFilter struct {
+	UserId    *int
+	ProductId *int
+	// Interval sales updated within the postgres interval before now, e.g. "7 days"
+	Interval *string
+}
+
+// SellsList page of sells
+type SellsList struct {
+	Sells      []Sell
+	NextCursor string
+}
+
+// SalesTimeSeriesFilter period [From, To) split into buckets of the Location calendar
+type SalesTimeSeriesFilter struct {
+	From     time.Time
+	To       time.Time
+	Bucket   string
+	Location *time.Location
+}
+
+// SalesBucket sales made during one bucket of a time series
+type SalesBucket struct {
+	Start   time.Time `json:"start"`
+	Count   int64     `json:"count"`
+	Revenue int64     `json:"revenue"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Session server-side login session, the cookie carries a secret token and ID is its hash
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     int       `json:"user_id"`
+	Login      string    `json:"login"`
+	Role       string    `json:"role"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// SessionResponse session as listed to its user, Current marks the session of the request
+type SessionResponse struct {
+	Session
+	Current bool `json:"current"`
+}
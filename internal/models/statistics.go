@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// StatisticsFilter sales period and size of top lists
+type StatisticsFilter struct {
+	From  *time.Time
+	To    *time.Time
+	Limit int
+}
+
+// ProductStatistics units sold and revenue of a product
+type ProductStatistics struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	Units     int64  `json:"units"`
+	Revenue   int64  `json:"revenue"`
+}
+
+// BuyerStatistics purchases made by a user
+type BuyerStatistics struct {
+	UserID    int    `json:"user_id"`
+	Name      string `json:"name"`
+	Purchases int64  `json:"purchases"`
+	Spent     int64  `json:"spent"`
+}
+
+// DailySales sales made during one day
+type DailySales struct {
+	Date    time.Time `json:"date"`
+	Count   int64     `json:"count"`
+	Revenue int64     `json:"revenue"`
+}
+
+// BasketStatistics average purchases of one user during one day
+type BasketStatistics struct {
+	Items   float64 `json:"items"`
+	Revenue float64 `json:"revenue"`
+}
+
+// SalesStatistics sales per day and average basket
+type SalesStatistics struct {
+	Daily         []DailySales     `json:"daily"`
+	AverageBasket BasketStatistics `json:"average_basket"`
+}
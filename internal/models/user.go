@@ -2,6 +2,8 @@ package models
 
 import (
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
@@ -12,3 +14,52 @@ type User struct {
 	Password  string
 	IsAdmin   bool
 }
+
+// UserResponse user representation safe to send to clients, never carries the password hash
+type UserResponse struct {
+	ID        int              `json:"id"`
+	Name      string           `json:"name"`
+	UpdatedAt pgtype.Timestamp `json:"updated_at" swaggertype:"string" format:"date-time"`
+	Login     string           `json:"login"`
+	IsAdmin   bool             `json:"is_admin"`
+}
+
+// HashPassword replace plain text password with its bcrypt hash
+func (u *User) HashPassword() error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.Wrap(err, "bcrypt.GenerateFromPassword")
+	}
+	u.Password = string(hashedPassword)
+	return nil
+}
+
+// ComparePasswords compare stored password hash with plain text password
+func (u *User) ComparePasswords(password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password)); err != nil {
+		return errors.Wrap(err, "bcrypt.CompareHashAndPassword")
+	}
+	return nil
+}
+
+// Response user without sensitive fields
+func (u *User) Response() UserResponse {
+	return UserResponse{
+		ID:        u.ID,
+		Name:      u.Name,
+		UpdatedAt: u.UpdatedAt,
+		Login:     u.Login,
+		IsAdmin:   u.IsAdmin,
+	}
+}
+
+// UserFilter optional conditions for users list
+type UserFilter struct {
+	IsAdmin *bool
+}
+
+// UsersList page of users
+type UsersList struct {
+	Users      []User
+	NextCursor string
+}
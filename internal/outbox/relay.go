@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+const (
+	relayInterval  = 1 * time.Second
+	relayBatchSize = 100
+	// claimLease must outlast a publish of a batch, an expired lease lets another relay publish the batch again
+	claimLease     = 1 * time.Minute
+	purgeBatchSize = 1000
+)
+
+// Topics Kafka topic of each aggregate events
+var Topics = map[string]string{
+	AggregateProduct: "products-events",
+	AggregateUser:    "users-events",
+	AggregateSell:    "sales-events",
+}
+
+// Relay publishes outbox events to Kafka, an event may be published more than once.
+// Sent events are deleted once they are older than the retention period
+type Relay struct {
+	log           logger.Logger
+	repo          OutboxRepository
+	writer        *kafka.Writer
+	retention     time.Duration
+	purgeInterval time.Duration
+}
+
+// NewRelay Relay constructor, writer must not have a topic set
+func NewRelay(log logger.Logger, repo OutboxRepository, writer *kafka.Writer, retention, purgeInterval time.Duration) *Relay {
+	return &Relay{log: log, repo: repo, writer: writer, retention: retention, purgeInterval: purgeInterval}
+}
+
+// Run publish events and purge sent ones until ctx is done
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(relayInterval)
+	defer ticker.Stop()
+	purgeTicker := time.NewTicker(r.purgeInterval)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.relay(ctx)
+		case <-purgeTicker.C:
+			r.purge(ctx)
+		}
+	}
+}
+
+// relay publish claimed batches until fewer than a batch is left
+func (r *Relay) relay(ctx context.Context) {
+	for {
+		count, err := r.relayBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.log.Errorf("outbox relay: %v", err)
+			}
+			return
+		}
+		if count < relayBatchSize {
+			return
+		}
+	}
+}
+
+// relayBatch claim a batch, publish it outside of any transaction and mark it sent
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	events, err := r.repo.Claim(ctx, relayBatchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, 0, len(events))
+	for _, ev := range events {
+		ids = append(ids, ev.ID)
+	}
+
+	if err := r.publish(ctx, events); err != nil {
+		if ctx.Err() == nil {
+			if releaseErr := r.repo.Release(ctx, ids); releaseErr != nil {
+				r.log.Errorf("outbox relay: %v", releaseErr)
+			}
+		}
+		return 0, errors.Wrap(err, "publish")
+	}
+	if err := r.repo.MarkSent(ctx, ids); err != nil {
+		return 0, err
+	}
+	return len(events), nil
+}
+
+// purge delete events sent before the retention period in batches
+func (r *Relay) purge(ctx context.Context) {
+	before := time.Now().Add(-r.retention)
+	var total int64
+	for {
+		deleted, err := r.repo.PurgeSent(ctx, before, purgeBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.log.Errorf("outbox purge: %v", err)
+			}
+			return
+		}
+		total += deleted
+		if deleted < purgeBatchSize {
+			break
+		}
+	}
+	if total > 0 {
+		r.log.Infof("outbox purge: deleted %d sent events", total)
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, events []models.OutboxEvent) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, ev := range events {
+		topic, ok := Topics[ev.Aggregate]
+		if !ok {
+			return errors.Errorf("no topic for aggregate %s", ev.Aggregate)
+		}
+		value, err := json.Marshal(models.EventMessage{
+			ID:          ev.ID,
+			Aggregate:   ev.Aggregate,
+			AggregateID: ev.AggregateID,
+			Type:        ev.Type,
+			Payload:     ev.Payload,
+			CreatedAt:   ev.CreatedAt,
+		})
+		if err != nil {
+			return errors.Wrap(err, "json.Marshal")
+		}
+		messages = append(messages, kafka.Message{
+			Topic: topic,
+			Key:   []byte(ev.AggregateID),
+			Value: value,
+		})
+	}
+	return r.writer.WriteMessages(ctx, messages...)
+}
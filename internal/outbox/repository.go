@@ -0,0 +1,30 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+)
+
+const (
+	AggregateProduct = "product"
+	AggregateUser    = "user"
+	AggregateSell    = "sell"
+
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// OutboxRepository unsent events
+type OutboxRepository interface {
+	// Claim lease up to limit unsent events ordered by id, other relays skip them until the lease expires
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error)
+	// MarkSent mark claimed events sent
+	MarkSent(ctx context.Context, ids []int64) error
+	// Release drop the lease of claimed events so they are retried without waiting for it to expire
+	Release(ctx context.Context, ids []int64) error
+	// PurgeSent delete up to limit events sent before the given time, returns the number of deleted rows
+	PurgeSent(ctx context.Context, before time.Time, limit int) (int64, error)
+}
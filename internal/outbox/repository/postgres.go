@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/outbox"
+	"github.com/Lidne/praktika_MAI/pkg/postgres"
+)
+
+// outboxRepo
+type outboxRepo struct {
+	client postgres.Client
+}
+
+// NewOutboxRepo outboxRepo constructor
+func NewOutboxRepo(client postgres.Client) outbox.OutboxRepository {
+	return &outboxRepo{client: client}
+}
+
+// Write insert event row using the transaction of the aggregate change
+func Write(ctx context.Context, tx pgx.Tx, aggregate, aggregateID, eventType string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+	q := `INSERT INTO outbox (aggregate, aggregate_id, event_type, payload) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.Exec(ctx, q, aggregate, aggregateID, aggregate+"."+eventType, body); err != nil {
+		return errors.Wrap(err, "outbox.Write.Exec")
+	}
+	return nil
+}
+
+func (r *outboxRepo) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxEvent, error) {
+	q := `UPDATE outbox SET claimed_until = (now() AT TIME ZONE 'UTC') + $2 * interval '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at IS NULL AND (claimed_until IS NULL OR claimed_until < now() AT TIME ZONE 'UTC')
+			ORDER BY id LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, aggregate, aggregate_id, event_type, payload, created_at`
+	rows, err := r.client.Query(ctx, q, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, errors.Wrap(err, "outboxRepo.Claim.Query")
+	}
+	events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.OutboxEvent, error) {
+		var ev models.OutboxEvent
+		err := row.Scan(&ev.ID, &ev.Aggregate, &ev.AggregateID, &ev.Type, &ev.Payload, &ev.CreatedAt)
+		return ev, err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "outboxRepo.Claim.Scan")
+	}
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+func (r *outboxRepo) MarkSent(ctx context.Context, ids []int64) error {
+	q := `UPDATE outbox SET sent_at = now() AT TIME ZONE 'UTC', claimed_until = NULL WHERE id = ANY($1)`
+	if _, err := r.client.Exec(ctx, q, ids); err != nil {
+		return errors.Wrap(err, "outboxRepo.MarkSent.Exec")
+	}
+	return nil
+}
+
+func (r *outboxRepo) Release(ctx context.Context, ids []int64) error {
+	q := `UPDATE outbox SET claimed_until = NULL WHERE id = ANY($1) AND sent_at IS NULL`
+	if _, err := r.client.Exec(ctx, q, ids); err != nil {
+		return errors.Wrap(err, "outboxRepo.Release.Exec")
+	}
+	return nil
+}
+
+func (r *outboxRepo) PurgeSent(ctx context.Context, before time.Time, limit int) (int64, error) {
+	q := `DELETE FROM outbox WHERE id IN (
+			SELECT id FROM outbox WHERE sent_at < $1 ORDER BY sent_at LIMIT $2
+		)`
+	tag, err := r.client.Exec(ctx, q, before.UTC(), limit)
+	if err != nil {
+		return 0, errors.Wrap(err, "outboxRepo.PurgeSent.Exec")
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/outbox"
+	"github.com/Lidne/praktika_MAI/pkg/postgres/postgrestest"
+)
+
+// writeEvents insert n product events, their ids are 1..n
+func writeEvents(t *testing.T, pool *pgxpool.Pool, n int) {
+	t.Helper()
+	err := pgx.BeginFunc(context.Background(), pool, func(tx pgx.Tx) error {
+		for i := 1; i <= n; i++ {
+			if err := Write(context.Background(), tx, outbox.AggregateProduct, strconv.Itoa(i), outbox.EventCreated, models.Product{ID: i}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+}
+
+func ids(events []models.OutboxEvent) []int64 {
+	res := []int64{}
+	for _, ev := range events {
+		res = append(res, ev.ID)
+	}
+	return res
+}
+
+func claim(t *testing.T, repo outbox.OutboxRepository, limit int, lease time.Duration, want ...int64) {
+	t.Helper()
+	events, err := repo.Claim(context.Background(), limit, lease)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if got := ids(events); fmt.Sprint(got) != fmt.Sprint(append([]int64{}, want...)) {
+		t.Fatalf("Claim() = %v, want %v", got, want)
+	}
+}
+
+func TestClaim(t *testing.T) {
+	pool := postgrestest.New(t)
+	repo := NewOutboxRepo(pool)
+	writeEvents(t, pool, 3)
+
+	events, err := repo.Claim(context.Background(), 2, time.Minute)
+	if err != nil {
+		t.Fatalf("Claim() error = %v", err)
+	}
+	if len(events) != 2 || events[0].Type != "product.created" || events[0].AggregateID != "1" {
+		t.Fatalf("Claim() = %+v, want the first two product.created events", events)
+	}
+	// claimed rows are skipped until the lease expires
+	claim(t, repo, 2, time.Minute, 3)
+	claim(t, repo, 2, time.Minute)
+
+	if err := repo.Release(context.Background(), []int64{2}); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	claim(t, repo, 10, time.Minute, 2)
+}
+
+func TestClaimLeaseExpiry(t *testing.T) {
+	pool := postgrestest.New(t)
+	repo := NewOutboxRepo(pool)
+	writeEvents(t, pool, 2)
+
+	claim(t, repo, 10, 50*time.Millisecond, 1, 2)
+	claim(t, repo, 10, time.Minute)
+
+	time.Sleep(100 * time.Millisecond)
+	claim(t, repo, 10, time.Minute, 1, 2)
+}
+
+func TestMarkSent(t *testing.T) {
+	pool := postgrestest.New(t)
+	repo := NewOutboxRepo(pool)
+	writeEvents(t, pool, 3)
+
+	claim(t, repo, 10, time.Minute, 1, 2, 3)
+	if err := repo.MarkSent(context.Background(), []int64{1, 3}); err != nil {
+		t.Fatalf("MarkSent() error = %v", err)
+	}
+	// releasing a sent event does not make it pending again
+	if err := repo.Release(context.Background(), []int64{1, 2, 3}); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	claim(t, repo, 10, time.Minute, 2)
+}
+
+func TestConcurrentClaims(t *testing.T) {
+	pool := postgrestest.New(t)
+	repo := NewOutboxRepo(pool)
+	writeEvents(t, pool, 20)
+
+	var mu sync.Mutex
+	claimed := map[int64]int{}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				events, err := repo.Claim(context.Background(), 3, time.Minute)
+				if err != nil {
+					t.Errorf("Claim() error = %v", err)
+					return
+				}
+				if len(events) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, ev := range events {
+					claimed[ev.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(claimed) != 20 {
+		t.Errorf("claimed %d events, want 20", len(claimed))
+	}
+	for id, n := range claimed {
+		if n != 1 {
+			t.Errorf("event %d claimed %d times", id, n)
+		}
+	}
+}
+
+func TestPurgeSent(t *testing.T) {
+	pool := postgrestest.New(t)
+	repo := NewOutboxRepo(pool)
+	ctx := context.Background()
+	writeEvents(t, pool, 5)
+
+	if err := repo.MarkSent(ctx, []int64{1, 2, 3, 4}); err != nil {
+		t.Fatalf("MarkSent() error = %v", err)
+	}
+	q := `UPDATE outbox SET sent_at = (now() AT TIME ZONE 'UTC') - interval '2 days' WHERE id IN (1, 2, 3)`
+	if _, err := pool.Exec(ctx, q); err != nil {
+		t.Fatalf("age events: %v", err)
+	}
+
+	before := time.Now().Add(-24 * time.Hour)
+	for _, want := range []int64{2, 1, 0} {
+		n, err := repo.PurgeSent(ctx, before, 2)
+		if err != nil {
+			t.Fatalf("PurgeSent() error = %v", err)
+		}
+		if n != want {
+			t.Fatalf("PurgeSent() = %d, want %d", n, want)
+		}
+	}
+
+	rows, err := pool.Query(ctx, `SELECT id FROM outbox ORDER BY id`)
+	if err != nil {
+		t.Fatalf("select outbox: %v", err)
+	}
+	left, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		t.Fatalf("select outbox: %v", err)
+	}
+	if fmt.Sprint(left) != "[4 5]" {
+		t.Errorf("outbox ids after purge = %v, want [4 5], recent and unsent events are kept", left)
+	}
+}
@@ -0,0 +1,148 @@
+package grpc
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/product"
+	grpcErrors "github.com/Lidne/praktika_MAI/pkg/grpc_errors"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+	productsService "github.com/Lidne/praktika_MAI/proto/product"
+)
+
+// MethodPermissions permissions required by the methods changing the catalog, other methods are public
+var MethodPermissions = map[string]auth.Permission{
+	"/productsService.ProductsService/Create": auth.PermCatalogWrite,
+	"/productsService.ProductsService/Update": auth.PermCatalogWrite,
+}
+
+// productService gRPC products service
+type productService struct {
+	productsService.UnimplementedProductsServiceServer
+	log         logger.Logger
+	productRepo product.ProductRepository
+}
+
+// NewProductService productService constructor
+func NewProductService(log logger.Logger, productRepo product.ProductRepository) *productService {
+	return &productService{log: log, productRepo: productRepo}
+}
+
+// Create create new product
+func (p *productService) Create(ctx context.Context, req *productsService.CreateReq) (*productsService.CreateRes, error) {
+	prod, err := productFromRequest(req.GetName(), req.GetDescription(), req.GetPrice())
+	if err != nil {
+		return nil, grpcErrors.ErrorResponse(err, "productFromRequest")
+	}
+
+	if err := p.productRepo.Create(ctx, prod); err != nil {
+		p.log.Errorf("productRepo.Create: %v", err)
+		return nil, grpcErrors.ErrorResponse(err, "productRepo.Create")
+	}
+
+	return &productsService.CreateRes{Product: productToProto(prod)}, nil
+}
+
+// Update update existing product
+func (p *productService) Update(ctx context.Context, req *productsService.UpdateReq) (*productsService.UpdateRes, error) {
+	prod, err := productFromRequest(req.GetName(), req.GetDescription(), req.GetPrice())
+	if err != nil {
+		return nil, grpcErrors.ErrorResponse(err, "productFromRequest")
+	}
+	if prod.ID, err = strconv.Atoi(req.GetProductID()); err != nil {
+		return nil, grpcErrors.ErrorResponse(errors.Wrap(grpcErrors.ErrInvalidArgument, "ProductID"), "strconv.Atoi")
+	}
+
+	if err := p.productRepo.Update(ctx, prod); err != nil {
+		p.log.Errorf("productRepo.Update: %v", err)
+		return nil, grpcErrors.ErrorResponse(err, "productRepo.Update")
+	}
+
+	prod, err = p.productRepo.GetByID(ctx, req.GetProductID())
+	if err != nil {
+		p.log.Errorf("productRepo.GetByID: %v", err)
+		return nil, grpcErrors.ErrorResponse(err, "productRepo.GetByID")
+	}
+
+	return &productsService.UpdateRes{Product: productToProto(prod)}, nil
+}
+
+// GetByID get single product by id
+func (p *productService) GetByID(ctx context.Context, req *productsService.GetByIDReq) (*productsService.GetByIDRes, error) {
+	if _, err := strconv.Atoi(req.GetProductID()); err != nil {
+		return nil, grpcErrors.ErrorResponse(errors.Wrap(grpcErrors.ErrInvalidArgument, "ProductID"), "strconv.Atoi")
+	}
+
+	prod, err := p.productRepo.GetByID(ctx, req.GetProductID())
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			p.log.Errorf("productRepo.GetByID: %v", err)
+		}
+		return nil, grpcErrors.ErrorResponse(err, "productRepo.GetByID")
+	}
+
+	return &productsService.GetByIDRes{Product: productToProto(prod)}, nil
+}
+
+// Search full-text search over products
+func (p *productService) Search(ctx context.Context, req *productsService.SearchReq) (*productsService.SearchRes, error) {
+	if strings.TrimSpace(req.GetSearch()) == "" {
+		return nil, grpcErrors.ErrorResponse(errors.Wrap(grpcErrors.ErrInvalidArgument, "Search is required"), "Search")
+	}
+	query, err := pagination.NewPageQuery(req.GetPage(), req.GetSize())
+	if err != nil {
+		return nil, grpcErrors.ErrorResponse(errors.Wrap(grpcErrors.ErrInvalidArgument, err.Error()), "pagination.NewPageQuery")
+	}
+
+	list, err := p.productRepo.Search(ctx, req.GetSearch(), query)
+	if err != nil {
+		p.log.Errorf("productRepo.Search: %v", err)
+		return nil, grpcErrors.ErrorResponse(err, "productRepo.Search")
+	}
+
+	products := make([]*productsService.Product, 0, len(list.Products))
+	for i := range list.Products {
+		products = append(products, productToProto(&list.Products[i]))
+	}
+
+	return &productsService.SearchRes{
+		TotalCount: list.TotalCount,
+		TotalPages: list.TotalPages,
+		Page:       list.Page,
+		Size:       list.Size,
+		HasMore:    list.HasMore,
+		Products:   products,
+	}, nil
+}
+
+func productFromRequest(name, description string, price float64) (*models.Product, error) {
+	if name == "" {
+		return nil, errors.Wrap(grpcErrors.ErrInvalidArgument, "Name is required")
+	}
+	if price < 0 || price != math.Trunc(price) || price > math.MaxInt32 {
+		return nil, errors.Wrap(grpcErrors.ErrInvalidArgument, "Price must be a non negative whole number")
+	}
+	return &models.Product{Name: name, Description: description, Price: int(price)}, nil
+}
+
+func productToProto(prod *models.Product) *productsService.Product {
+	res := &productsService.Product{
+		ProductID:   strconv.Itoa(prod.ID),
+		Name:        prod.Name,
+		Description: prod.Description,
+		Price:       float64(prod.Price),
+	}
+	if prod.CreatedAt.Valid {
+		res.UpdatedAt = timestamppb.New(prod.CreatedAt.Time)
+	}
+	return res
+}
@@ -0,0 +1,308 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/product"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	httpUtils "github.com/Lidne/praktika_MAI/pkg/http_utils"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+type handlers struct {
+	products product.ProductRepository
+	validate *validator.Validate
+}
+
+// getProducts godoc
+//
+//	@Summary		Get Products
+//	@Tags			Products
+//	@Description	Get a page of products
+//	@ID				get-products
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit		query	int		false	"Page size"
+//	@Param			after		query	string	false	"Cursor returned as next_cursor"
+//	@Param			sort		query	string	false	"Sort field: id, name, price, updated_at, prefix with - for descending order"
+//	@Param			min_price	query	int		false	"Minimal price"
+//	@Param			max_price	query	int		false	"Maximal price"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/products [get]
+func (h *handlers) getProducts(c echo.Context) error {
+	query, err := httpUtils.PaginationQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	filter := &models.ProductFilter{}
+	if filter.MinPrice, err = httpUtils.QueryInt(c, "min_price"); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if filter.MaxPrice, err = httpUtils.QueryInt(c, "max_price"); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+
+	list, err := h.products.FindAll(c.Request().Context(), query, filter)
+	if err != nil {
+		return httpUtils.ListErrorResponse(c, err)
+	}
+	res := []echo.Map{}
+	for i := range list.Products {
+		res = append(res, productResponse(&list.Products[i]))
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data":        res,
+		"next_cursor": list.NextCursor,
+	})
+}
+
+// getProductById godoc
+//
+//	@Summary		Get Product By ID
+//	@Tags			Products
+//	@Description	Get a product by ID
+//	@ID				get-product-by-id
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	string	true	"Product ID"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		404	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/products/{id} [get]
+func (h *handlers) getProductById(c echo.Context) error {
+	productId := c.Param("id")
+	product, err := h.products.GetByID(c.Request().Context(), productId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": productResponse(product),
+	})
+}
+
+type productRequest struct {
+	Name        string `json:"name" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=4096"`
+	Price       int    `json:"price" validate:"gte=0"`
+}
+
+type productPatchRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=255"`
+	Description *string `json:"description" validate:"omitempty,max=4096"`
+	Price       *int    `json:"price" validate:"omitempty,gte=0"`
+}
+
+func productResponse(product *models.Product) echo.Map {
+	return echo.Map{
+		"id":          product.ID,
+		"updated_at":  product.CreatedAt,
+		"name":        product.Name,
+		"description": product.Description,
+		"price":       product.Price,
+	}
+}
+
+// searchProducts godoc
+//
+//	@Summary		Search Products
+//	@Tags			Products
+//	@Description	Full-text search over product name and description ordered by relevance
+//	@ID				search-products
+//	@Accept			json
+//	@Produce		json
+//	@Param			q		query	string	true	"Search query"
+//	@Param			page	query	int		false	"Page number starting from 1"
+//	@Param			size	query	int		false	"Page size"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/products/search [get]
+func (h *handlers) searchProducts(c echo.Context) error {
+	search := strings.TrimSpace(c.QueryParam("q"))
+	if search == "" {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("q is required"))
+	}
+	query, err := pagination.ParsePageQuery(c.QueryParam("page"), c.QueryParam("size"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+
+	list, err := h.products.Search(c.Request().Context(), search, query)
+	if err != nil {
+		return err
+	}
+	res := []echo.Map{}
+	for i := range list.Products {
+		res = append(res, productResponse(&list.Products[i]))
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data":        res,
+		"total_count": list.TotalCount,
+		"total_pages": list.TotalPages,
+		"page":        list.Page,
+		"size":        list.Size,
+		"has_more":    list.HasMore,
+	})
+}
+
+// createProduct godoc
+//
+//	@Summary		Create Product
+//	@Tags			Products
+//	@Description	Create a new product
+//	@ID				create-product
+//	@Accept			json
+//	@Produce		json
+//	@Param			product	body	productRequest	true	"Product"
+//	@Success		201	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/products [post]
+func (h *handlers) createProduct(c echo.Context) error {
+	var req productRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if err := h.validate.StructCtx(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	product := &models.Product{Name: req.Name, Description: req.Description, Price: req.Price}
+	if err := h.products.Create(c.Request().Context(), product); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"data": productResponse(product),
+	})
+}
+
+// updateProduct godoc
+//
+//	@Summary		Update Product
+//	@Tags			Products
+//	@Description	Replace all fields of a product
+//	@ID				update-product
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int				true	"Product ID"
+//	@Param			product	body	productRequest	true	"Product"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		404	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/products/{id} [put]
+func (h *handlers) updateProduct(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid product id"))
+	}
+	var req productRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if err := h.validate.StructCtx(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	product := &models.Product{ID: productId, Name: req.Name, Description: req.Description, Price: req.Price}
+	if err := h.products.Update(c.Request().Context(), product); err != nil {
+		return err
+	}
+	product, err = h.products.GetByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": productResponse(product),
+	})
+}
+
+// patchProduct godoc
+//
+//	@Summary		Patch Product
+//	@Tags			Products
+//	@Description	Update only the given fields of a product
+//	@ID				patch-product
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int					true	"Product ID"
+//	@Param			product	body	productPatchRequest	true	"Product fields"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		404	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/products/{id} [patch]
+func (h *handlers) patchProduct(c echo.Context) error {
+	if _, err := strconv.Atoi(c.Param("id")); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid product id"))
+	}
+	var req productPatchRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if err := h.validate.StructCtx(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	product, err := h.products.GetByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if req.Name != nil {
+		product.Name = *req.Name
+	}
+	if req.Description != nil {
+		product.Description = *req.Description
+	}
+	if req.Price != nil {
+		product.Price = *req.Price
+	}
+	if err := h.products.Update(c.Request().Context(), product); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": productResponse(product),
+	})
+}
+
+// deleteProduct godoc
+//
+//	@Summary		Delete Product
+//	@Tags			Products
+//	@Description	Delete a product by ID
+//	@ID				delete-product
+//	@Param			id	path	int	true	"Product ID"
+//	@Success		204
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		404	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/products/{id} [delete]
+func (h *handlers) deleteProduct(c echo.Context) error {
+	productId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid product id"))
+	}
+	if err := h.products.Delete(c.Request().Context(), productId); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
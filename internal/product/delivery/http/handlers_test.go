@@ -0,0 +1,259 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/middlewares"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Errorf(template string, args ...interface{}) {}
+
+// fakeProducts in-memory product repository
+type fakeProducts struct {
+	products map[int]models.Product
+}
+
+func (r *fakeProducts) Create(ctx context.Context, product *models.Product) error {
+	product.ID = 100
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *fakeProducts) Update(ctx context.Context, product *models.Product) error {
+	if _, ok := r.products[product.ID]; !ok {
+		return pgx.ErrNoRows
+	}
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *fakeProducts) GetByID(ctx context.Context, id string) (*models.Product, error) {
+	productID, _ := strconv.Atoi(id)
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &product, nil
+}
+
+func (r *fakeProducts) FindAll(ctx context.Context, query *pagination.Query, filter *models.ProductFilter) (*models.ProductsList, error) {
+	return &models.ProductsList{}, nil
+}
+
+func (r *fakeProducts) Delete(ctx context.Context, id int) error {
+	if _, ok := r.products[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(r.products, id)
+	return nil
+}
+
+func (r *fakeProducts) Search(ctx context.Context, search string, query *pagination.PageQuery) (*models.ProductsSearchList, error) {
+	return &models.ProductsSearchList{}, nil
+}
+
+var (
+	admin = &auth.Claims{ID: 1, Login: "admin", Role: auth.RoleAdmin}
+	buyer = &auth.Claims{ID: 2, Login: "bob", Role: auth.RoleUser}
+)
+
+func TestProductWrites(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *auth.Claims
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		// wantProduct product 1 in the repository after the request, nil when it is deleted
+		wantProduct *models.Product
+		wantName    string
+	}{
+		{
+			name:        "create",
+			claims:      admin,
+			method:      http.MethodPost,
+			target:      "/api/products",
+			body:        `{"name": "cup", "description": "white", "price": 300}`,
+			wantStatus:  http.StatusCreated,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+			wantName:    "cup",
+		},
+		{
+			name:        "create invalid",
+			claims:      admin,
+			method:      http.MethodPost,
+			target:      "/api/products",
+			body:        `{"name": "", "price": -1}`,
+			wantStatus:  http.StatusBadRequest,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+		{
+			name:        "create malformed",
+			claims:      admin,
+			method:      http.MethodPost,
+			target:      "/api/products",
+			body:        `{"name": `,
+			wantStatus:  http.StatusBadRequest,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+		{
+			name:        "create by a buyer",
+			claims:      buyer,
+			method:      http.MethodPost,
+			target:      "/api/products",
+			body:        `{"name": "cup", "price": 300}`,
+			wantStatus:  http.StatusForbidden,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+		{
+			name:        "create anonymously",
+			method:      http.MethodPost,
+			target:      "/api/products",
+			body:        `{"name": "cup", "price": 300}`,
+			wantStatus:  http.StatusUnauthorized,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+		{
+			name:        "update",
+			claims:      admin,
+			method:      http.MethodPut,
+			target:      "/api/products/1",
+			body:        `{"name": "green tea", "price": 150}`,
+			wantStatus:  http.StatusOK,
+			wantProduct: &models.Product{ID: 1, Name: "green tea", Price: 150},
+			wantName:    "green tea",
+		},
+		{
+			name:        "update missing",
+			claims:      admin,
+			method:      http.MethodPut,
+			target:      "/api/products/42",
+			body:        `{"name": "green tea", "price": 150}`,
+			wantStatus:  http.StatusNotFound,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+		{
+			name:        "patch price",
+			claims:      admin,
+			method:      http.MethodPatch,
+			target:      "/api/products/1",
+			body:        `{"price": 120}`,
+			wantStatus:  http.StatusOK,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 120},
+			wantName:    "tea",
+		},
+		{
+			name:        "patch missing",
+			claims:      admin,
+			method:      http.MethodPatch,
+			target:      "/api/products/42",
+			body:        `{"price": 120}`,
+			wantStatus:  http.StatusNotFound,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+		{
+			name:       "delete",
+			claims:     admin,
+			method:     http.MethodDelete,
+			target:     "/api/products/1",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:        "delete missing",
+			claims:      admin,
+			method:      http.MethodDelete,
+			target:      "/api/products/42",
+			wantStatus:  http.StatusNotFound,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+		{
+			name:        "delete invalid id",
+			claims:      admin,
+			method:      http.MethodDelete,
+			target:      "/api/products/tea",
+			wantStatus:  http.StatusBadRequest,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+		{
+			name:        "delete by a buyer",
+			claims:      buyer,
+			method:      http.MethodDelete,
+			target:      "/api/products/1",
+			wantStatus:  http.StatusForbidden,
+			wantProduct: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products := &fakeProducts{products: map[int]models.Product{1: {ID: 1, Name: "tea", Price: 100}}}
+			e := echo.New()
+			e.HTTPErrorHandler = httpErrors.NewHTTPErrorHandler(nopLogger{})
+			api := e.Group("/api", func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if tt.claims != nil {
+						claims := *tt.claims
+						auth.SetClaims(c, &claims)
+					}
+					return next(c)
+				}
+			})
+			NewRouter(api, products, validator.New(), middlewares.NewMiddlewareManager(nopLogger{}, &config.Config{}, nil, nil))
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantName != "" {
+				var body struct {
+					Data struct {
+						ID   int    `json:"id"`
+						Name string `json:"name"`
+					} `json:"data"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+					t.Fatalf("json.Unmarshal: %v", err)
+				}
+				if body.Data.Name != tt.wantName || body.Data.ID == 0 {
+					t.Errorf("data = %+v, want product %s", body.Data, tt.wantName)
+				}
+			}
+
+			got, ok := products.products[1]
+			if tt.wantProduct == nil {
+				if ok {
+					t.Errorf("product 1 = %+v, want deleted", got)
+				}
+				return
+			}
+			if !ok || got != *tt.wantProduct {
+				t.Errorf("product 1 = %+v, want %+v", got, *tt.wantProduct)
+			}
+		})
+	}
+}
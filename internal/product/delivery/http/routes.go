@@ -0,0 +1,23 @@
+package http
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/middlewares"
+	"github.com/Lidne/praktika_MAI/internal/product"
+)
+
+// NewRouter register product routes
+func NewRouter(e *echo.Group, products product.ProductRepository, validate *validator.Validate, mw middlewares.MiddlewareManager) {
+	h := &handlers{products: products, validate: validate}
+	productsGroup := e.Group("/products")
+	productsGroup.GET("", h.getProducts)
+	productsGroup.GET("/search", h.searchProducts)
+	productsGroup.GET("/:id", h.getProductById)
+	productsGroup.POST("", h.createProduct, mw.RequirePermission(auth.PermCatalogWrite))
+	productsGroup.PUT("/:id", h.updateProduct, mw.RequirePermission(auth.PermCatalogWrite))
+	productsGroup.PATCH("/:id", h.patchProduct, mw.RequirePermission(auth.PermCatalogWrite))
+	productsGroup.DELETE("/:id", h.deleteProduct, mw.RequirePermission(auth.PermCatalogWrite))
+}
@@ -0,0 +1,153 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/compress"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/product"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+const (
+	minBytes               = 10e3 // 10KB
+	maxBytes               = 10e6 // 10MB
+	queueCapacity          = 100
+	heartbeatInterval      = 3 * time.Second
+	partitionWatchInterval = 5 * time.Second
+	maxAttempts            = 3
+	dialTimeout            = 3 * time.Minute
+
+	writerReadTimeout  = 10 * time.Second
+	writerWriteTimeout = 10 * time.Second
+	writerMaxAttempts  = 3
+
+	createProductTopic   = "create-product"
+	createProductWorkers = 16
+	updateProductTopic   = "update-product"
+	updateProductWorkers = 16
+	deadLetterQueueTopic = "dead-letter-queue"
+	workerQueueSize      = 16
+)
+
+// ProductsConsumerGroup struct
+type ProductsConsumerGroup struct {
+	Brokers     []string
+	GroupID     string
+	log         logger.Logger
+	cfg         *config.Config
+	productRepo product.ProductRepository
+	validate    *validator.Validate
+}
+
+// NewProductsConsumerGroup constructor
+func NewProductsConsumerGroup(
+	brokers []string,
+	groupID string,
+	log logger.Logger,
+	cfg *config.Config,
+	productRepo product.ProductRepository,
+	validate *validator.Validate,
+) *ProductsConsumerGroup {
+	return &ProductsConsumerGroup{
+		Brokers:     brokers,
+		GroupID:     groupID,
+		log:         log,
+		cfg:         cfg,
+		productRepo: productRepo,
+		validate:    validate,
+	}
+}
+
+func (pcg *ProductsConsumerGroup) getNewKafkaReader(kafkaURL []string, topic, groupID string) *kafka.Reader {
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:                kafkaURL,
+		GroupID:                groupID,
+		Topic:                  topic,
+		MinBytes:               minBytes,
+		MaxBytes:               maxBytes,
+		QueueCapacity:          queueCapacity,
+		HeartbeatInterval:      heartbeatInterval,
+		PartitionWatchInterval: partitionWatchInterval,
+		MaxAttempts:            maxAttempts,
+		Dialer: &kafka.Dialer{
+			Timeout: dialTimeout,
+		},
+	})
+}
+
+func (pcg *ProductsConsumerGroup) getNewKafkaWriter(topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(pcg.Brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.LeastBytes{},
+		RequiredAcks: kafka.RequireAll,
+		MaxAttempts:  writerMaxAttempts,
+		Compression:  compress.Snappy,
+		ReadTimeout:  writerReadTimeout,
+		WriteTimeout: writerWriteTimeout,
+	}
+}
+
+// consume fetch messages of the topic and dispatch them to workers by partition, every partition is owned
+// by one worker, so its messages are applied and committed in order. Blocks until ctx is done or fetching fails
+func (pcg *ProductsConsumerGroup) consume(ctx context.Context, topic string, workersNum int, handle handlerFunc) {
+	r := pcg.getNewKafkaReader(pcg.Brokers, topic, pcg.GroupID)
+	defer func() {
+		if err := r.Close(); err != nil {
+			pcg.log.Errorf("r.Close: %v", err)
+		}
+	}()
+
+	w := pcg.getNewKafkaWriter(deadLetterQueueTopic)
+	defer func() {
+		if err := w.Close(); err != nil {
+			pcg.log.Errorf("w.Close: %v", err)
+		}
+	}()
+
+	pcg.log.Infof("Starting consumer group: %v, topic: %s", r.Config().GroupID, topic)
+
+	wg := &sync.WaitGroup{}
+	queues := make([]chan kafka.Message, workersNum)
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, workerQueueSize)
+		wg.Add(1)
+		go func(workerID int) {
+			defer wg.Done()
+			pcg.work(ctx, r, w, workerID, queues[workerID], handle)
+		}(i)
+	}
+
+	pcg.fetch(ctx, r, queues)
+	for _, queue := range queues {
+		close(queue)
+	}
+	wg.Wait()
+}
+
+// RunConsumers run create and update product consumers, blocks until ctx is done and all workers exit.
+// When one consumer fails the other is stopped too, so that the caller sees the failure
+func (pcg *ProductsConsumerGroup) RunConsumers(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		pcg.consume(ctx, createProductTopic, createProductWorkers, pcg.createProduct)
+	}()
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		pcg.consume(ctx, updateProductTopic, updateProductWorkers, pcg.updateProduct)
+	}()
+	wg.Wait()
+}
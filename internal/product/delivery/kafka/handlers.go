@@ -0,0 +1,227 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+)
+
+const retryAttempts = 3
+
+var (
+	// retryDelay delay between attempts to handle a message and the first delay before it is processed again
+	retryDelay = 1 * time.Second
+	// maxRetryDelay longest delay before a message failing with a transient error is processed again
+	maxRetryDelay = 1 * time.Minute
+)
+
+// productMessage payload of create-product and update-product messages
+type productMessage struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=4096"`
+	Price       int    `json:"price" validate:"gte=0"`
+}
+
+// handlerFunc apply a decoded and validated message
+type handlerFunc func(ctx context.Context, msg *productMessage) error
+
+// committer commits offsets of the consumed messages, implemented by kafka.Reader
+type committer interface {
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// messageWriter publishes messages, implemented by kafka.Writer
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// poisonError the message itself is invalid and can never be applied
+type poisonError struct {
+	error
+}
+
+func (e poisonError) Unwrap() error {
+	return e.error
+}
+
+// isPoison whether err can not go away when the message is processed again: an undecodable or invalid payload,
+// a missing row, retry.Unrecoverable, a postgres data exception (class 22) or integrity constraint violation (class 23)
+func isPoison(err error) bool {
+	var poison poisonError
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &poison), !retry.IsRecoverable(err), errors.Is(err, pgx.ErrNoRows):
+		return true
+	case errors.As(err, &pgErr):
+		return strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")
+	}
+	return false
+}
+
+// createProduct is not idempotent: a message redelivered after its product was created, because the commit
+// failed or the consumer stopped in between, creates the product again. Producers that can not tolerate
+// duplicates must deduplicate the catalog by name or use the update-product topic with an id
+func (pcg *ProductsConsumerGroup) createProduct(ctx context.Context, msg *productMessage) error {
+	prod := &models.Product{Name: msg.Name, Description: msg.Description, Price: msg.Price}
+	return pcg.productRepo.Create(ctx, prod)
+}
+
+func (pcg *ProductsConsumerGroup) updateProduct(ctx context.Context, msg *productMessage) error {
+	if msg.ID <= 0 {
+		return retry.Unrecoverable(errors.New("id is required"))
+	}
+	prod := &models.Product{ID: msg.ID, Name: msg.Name, Description: msg.Description, Price: msg.Price}
+	return pcg.productRepo.Update(ctx, prod)
+}
+
+// fetch read messages until ctx is done or the reader fails and pass each to the worker owning its partition
+func (pcg *ProductsConsumerGroup) fetch(ctx context.Context, r *kafka.Reader, queues []chan kafka.Message) {
+	for {
+		m, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				pcg.log.Errorf("r.FetchMessage: %v", err)
+			}
+			return
+		}
+
+		select {
+		case queues[m.Partition%len(queues)] <- m:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// work apply messages of the worker's partitions one by one with handle.
+// An offset is committed only after its message is applied or moved to the dead letter queue, the worker
+// does not move on until one of them succeeds. Cancelling ctx stops the worker after the current message,
+// the uncommitted messages are redelivered
+func (pcg *ProductsConsumerGroup) work(ctx context.Context, r committer, w messageWriter, workerID int, queue <-chan kafka.Message, handle handlerFunc) {
+	for m := range queue {
+		if ctx.Err() != nil {
+			return
+		}
+
+		pcg.log.Debugf(
+			"workerID: %v, message at topic/partition/offset %v/%v/%v: %s",
+			workerID,
+			m.Topic,
+			m.Partition,
+			m.Offset,
+			string(m.Key),
+		)
+
+		if err := pcg.apply(ctx, w, workerID, m, handle); err != nil {
+			pcg.log.Errorf("workerID: %v, apply: %v", workerID, err)
+			return
+		}
+
+		if err := r.CommitMessages(context.WithoutCancel(ctx), m); err != nil {
+			pcg.log.Errorf("workerID: %v, r.CommitMessages: %v", workerID, err)
+		}
+	}
+}
+
+// apply process m until it is applied or, when it is poison, moved to the dead letter queue. A transient error
+// is retried with a doubling delay, so an unavailable database stops the partition instead of losing messages.
+// An error is returned only when ctx is done before that, then m must not be committed
+func (pcg *ProductsConsumerGroup) apply(ctx context.Context, w messageWriter, workerID int, m kafka.Message, handle handlerFunc) error {
+	delay := retryDelay
+	for {
+		err := pcg.process(context.WithoutCancel(ctx), m, handle)
+		if err == nil {
+			return nil
+		}
+		if isPoison(err) {
+			pcg.log.Errorf("workerID: %v, process: %v", workerID, err)
+			return pcg.deadLetter(ctx, w, m, err)
+		}
+
+		pcg.log.Warnf("workerID: %v, process at topic/partition/offset %v/%v/%v, retry in %v: %v", workerID, m.Topic, m.Partition, m.Offset, delay, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return errors.Wrap(err, "message is not committed")
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+// deadLetter publish the failed message to the dead letter queue, retrying until it succeeds or ctx is done
+func (pcg *ProductsConsumerGroup) deadLetter(ctx context.Context, w messageWriter, m kafka.Message, processErr error) error {
+	publish := func() error { return pcg.publishErrorMessage(ctx, w, m, processErr) }
+	for {
+		err := retry.Do(publish, retry.Attempts(retryAttempts), retry.Delay(retryDelay), retry.Context(ctx))
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return errors.Wrap(err, "message is not committed")
+		}
+		pcg.log.Errorf("publishErrorMessage at topic/partition/offset %v/%v/%v: %v", m.Topic, m.Partition, m.Offset, err)
+	}
+}
+
+// process decode, validate and handle m, retrying handle a few times. The errors of poison messages are
+// wrapped in poisonError, other errors are transient
+func (pcg *ProductsConsumerGroup) process(ctx context.Context, m kafka.Message, handle handlerFunc) error {
+	var msg productMessage
+	if err := json.Unmarshal(m.Value, &msg); err != nil {
+		return poisonError{errors.Wrap(err, "json.Unmarshal")}
+	}
+	if err := pcg.validate.StructCtx(ctx, &msg); err != nil {
+		return poisonError{errors.Wrap(err, "validate.StructCtx")}
+	}
+
+	return retry.Do(
+		func() error {
+			err := handle(ctx, &msg)
+			if err != nil && isPoison(err) {
+				return retry.Unrecoverable(poisonError{err})
+			}
+			return err
+		},
+		retry.Attempts(retryAttempts),
+		retry.Delay(retryDelay),
+		retry.Context(ctx),
+		retry.LastErrorOnly(true),
+	)
+}
+
+func (pcg *ProductsConsumerGroup) publishErrorMessage(ctx context.Context, w messageWriter, m kafka.Message, err error) error {
+	errMsg := &models.ErrorMessage{
+		MessageID: string(m.Key),
+		Offset:    m.Offset,
+		Partition: m.Partition,
+		Topic:     m.Topic,
+		Error:     err.Error(),
+		Time:      time.Now().UTC(),
+	}
+	if errMsg.MessageID == "" {
+		errMsg.MessageID = m.Topic + "/" + strconv.Itoa(m.Partition) + "/" + strconv.FormatInt(m.Offset, 10)
+	}
+
+	errMsgBytes, err := json.Marshal(errMsg)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+
+	return w.WriteMessages(ctx, kafka.Message{
+		Key:   m.Key,
+		Value: errMsgBytes,
+	})
+}
@@ -0,0 +1,281 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/avast/retry-go"
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/segmentio/kafka-go"
+
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+// nopLogger discards the messages logged by the consumer
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Debugf(template string, args ...interface{}) {}
+func (nopLogger) Warnf(template string, args ...interface{})  {}
+func (nopLogger) Errorf(template string, args ...interface{}) {}
+
+// fastRetries shorten the retry delays for the test
+func fastRetries(t *testing.T) {
+	delay, maxDelay := retryDelay, maxRetryDelay
+	retryDelay, maxRetryDelay = time.Millisecond, 4*time.Millisecond
+	t.Cleanup(func() { retryDelay, maxRetryDelay = delay, maxDelay })
+}
+
+func newTestGroup() *ProductsConsumerGroup {
+	return &ProductsConsumerGroup{log: nopLogger{}, validate: validator.New()}
+}
+
+const validMessage = `{"id": 1, "name": "tea", "price": 100}`
+
+func TestProcess(t *testing.T) {
+	fastRetries(t)
+
+	tests := []struct {
+		name       string
+		value      string
+		handleErr  error
+		wantCalls  int
+		wantErr    bool
+		wantPoison bool
+	}{
+		{name: "applied", value: validMessage, wantCalls: 1},
+		{name: "invalid json", value: `{"name": `, wantErr: true, wantPoison: true},
+		{name: "wrong type", value: `{"name": "tea", "price": "free"}`, wantErr: true, wantPoison: true},
+		{name: "invalid payload", value: `{"name": "", "price": -1}`, wantErr: true, wantPoison: true},
+		{
+			name:       "missing row",
+			value:      validMessage,
+			handleErr:  fmt.Errorf("productRepo.Update: %w", pgx.ErrNoRows),
+			wantCalls:  1,
+			wantErr:    true,
+			wantPoison: true,
+		},
+		{
+			name:       "unrecoverable",
+			value:      validMessage,
+			handleErr:  retry.Unrecoverable(errors.New("id is required")),
+			wantCalls:  1,
+			wantErr:    true,
+			wantPoison: true,
+		},
+		{
+			name:       "data exception",
+			value:      validMessage,
+			handleErr:  &pgconn.PgError{Code: "22001"},
+			wantCalls:  1,
+			wantErr:    true,
+			wantPoison: true,
+		},
+		{
+			name:       "constraint violation",
+			value:      validMessage,
+			handleErr:  fmt.Errorf("productRepo.Create: %w", &pgconn.PgError{Code: "23505"}),
+			wantCalls:  1,
+			wantErr:    true,
+			wantPoison: true,
+		},
+		{
+			name:      "serialization failure",
+			value:     validMessage,
+			handleErr: &pgconn.PgError{Code: "40001"},
+			wantCalls: retryAttempts,
+			wantErr:   true,
+		},
+		{
+			name:      "connection refused",
+			value:     validMessage,
+			handleErr: errors.New("dial tcp: connection refused"),
+			wantCalls: retryAttempts,
+			wantErr:   true,
+		},
+		{
+			name:      "deadline exceeded",
+			value:     validMessage,
+			handleErr: context.DeadlineExceeded,
+			wantCalls: retryAttempts,
+			wantErr:   true,
+		},
+	}
+
+	pcg := newTestGroup()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handle := func(ctx context.Context, msg *productMessage) error {
+				calls++
+				return tt.handleErr
+			}
+
+			err := pcg.process(context.Background(), kafka.Message{Value: []byte(tt.value)}, handle)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("process() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && isPoison(err) != tt.wantPoison {
+				t.Errorf("isPoison(%v) = %v, want %v", err, !tt.wantPoison, tt.wantPoison)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handle called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// events records commits and dead letters in the order they happen
+type events struct {
+	mu      sync.Mutex
+	log     []string
+	failDLQ bool
+}
+
+func (e *events) add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.log = append(e.log, event)
+}
+
+func (e *events) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string{}, e.log...)
+}
+
+func (e *events) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	for _, m := range msgs {
+		e.add(fmt.Sprintf("commit %d", m.Offset))
+	}
+	return nil
+}
+
+func (e *events) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if e.failDLQ {
+		return errors.New("leader not available")
+	}
+	for range msgs {
+		e.add("dead letter")
+	}
+	return nil
+}
+
+func TestWork(t *testing.T) {
+	fastRetries(t)
+
+	// results of handle for the messages by offset, a message not listed is applied
+	type results map[int64][]error
+	transient := errors.New("connection refused")
+	poison := pgx.ErrNoRows
+	repeat := func(err error, n int) []error {
+		errs := make([]error, n)
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	tests := []struct {
+		name     string
+		messages int
+		results  results
+		failDLQ  bool
+		// cancel ctx after this many handle calls, 0 never cancels
+		cancelAfter int
+		want        []string
+	}{
+		{
+			name:     "all applied",
+			messages: 3,
+			want:     []string{"commit 0", "commit 1", "commit 2"},
+		},
+		{
+			name:     "poison goes to the dead letter queue before its commit",
+			messages: 3,
+			results:  results{1: {poison}},
+			want:     []string{"commit 0", "dead letter", "commit 1", "commit 2"},
+		},
+		{
+			name:     "transient error is retried until applied",
+			messages: 2,
+			results:  results{0: repeat(transient, 3*retryAttempts)},
+			want:     []string{"commit 0", "commit 1"},
+		},
+		{
+			name:     "poison after transient errors",
+			messages: 2,
+			results:  results{0: append(repeat(transient, retryAttempts), poison)},
+			want:     []string{"dead letter", "commit 0", "commit 1"},
+		},
+		{
+			name:        "transient error until shutdown is not committed",
+			messages:    3,
+			results:     results{1: repeat(transient, 1000)},
+			cancelAfter: 5,
+			want:        []string{"commit 0"},
+		},
+		{
+			name:        "poison is not committed while the dead letter queue is down",
+			messages:    2,
+			results:     results{0: {poison}},
+			failDLQ:     true,
+			cancelAfter: 1,
+			want:        []string{},
+		},
+	}
+
+	pcg := newTestGroup()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := map[int64]int{}
+			total := 0
+			handle := func(ctx context.Context, msg *productMessage) error {
+				offset := int64(msg.ID)
+				total++
+				if total == tt.cancelAfter {
+					cancel()
+				}
+				errs := tt.results[offset]
+				if calls[offset] >= len(errs) {
+					return nil
+				}
+				calls[offset]++
+				return errs[calls[offset]-1]
+			}
+
+			queue := make(chan kafka.Message, tt.messages)
+			for i := 0; i < tt.messages; i++ {
+				queue <- kafka.Message{Offset: int64(i), Value: []byte(fmt.Sprintf(`{"id": %d, "name": "tea", "price": 1}`, i))}
+			}
+			close(queue)
+
+			e := &events{log: []string{}, failDLQ: tt.failDLQ}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				pcg.work(ctx, e, e, 0, queue, handle)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("work did not return")
+			}
+
+			got := e.get()
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
 )
 
 // ProductRepository Sell
@@ -11,6 +12,7 @@ type ProductRepository interface {
 	Create(ctx context.Context, product *models.Product) error
 	Update(ctx context.Context, product *models.Product) error
 	GetByID(ctx context.Context, id string) (*models.Product, error)
-	FindAll(ctx context.Context) ([]models.Product, error)
+	FindAll(ctx context.Context, query *pagination.Query, filter *models.ProductFilter) (*models.ProductsList, error)
 	Delete(ctx context.Context, id int) error
+	Search(ctx context.Context, search string, query *pagination.PageQuery) (*models.ProductsSearchList, error)
 }
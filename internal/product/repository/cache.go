@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/product"
+	"github.com/Lidne/praktika_MAI/pkg/cache"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+const productCacheName = "products"
+
+// productCacheRepo read-through cache in front of another product repository
+type productCacheRepo struct {
+	next    product.ProductRepository
+	cache   *cache.Cache
+	ttl     time.Duration
+	listTTL time.Duration
+}
+
+// NewProductCacheRepo productCacheRepo constructor
+func NewProductCacheRepo(next product.ProductRepository, client cache.Client, log logger.Logger, ttl, listTTL time.Duration) product.ProductRepository {
+	return &productCacheRepo{next: next, cache: cache.NewCache(client, log, productCacheName), ttl: ttl, listTTL: listTTL}
+}
+
+func (r *productCacheRepo) Create(ctx context.Context, product *models.Product) error {
+	if err := r.next.Create(ctx, product); err != nil {
+		return err
+	}
+	r.cache.Invalidate(ctx)
+	return nil
+}
+
+func (r *productCacheRepo) Update(ctx context.Context, product *models.Product) error {
+	if err := r.next.Update(ctx, product); err != nil {
+		return err
+	}
+	r.cache.Invalidate(ctx, strconv.Itoa(product.ID))
+	return nil
+}
+
+func (r *productCacheRepo) GetByID(ctx context.Context, id string) (*models.Product, error) {
+	key := r.cache.Key(id)
+	product := &models.Product{}
+	if r.cache.Get(ctx, key, product) {
+		return product, nil
+	}
+
+	version, versionErr := r.cache.Version(ctx)
+	product, err := r.next.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if versionErr == nil {
+		r.cache.SetFresh(ctx, version, key, product, r.ttl)
+	}
+	return product, nil
+}
+
+func (r *productCacheRepo) FindAll(ctx context.Context, query *pagination.Query, filter *models.ProductFilter) (*models.ProductsList, error) {
+	key, err := r.cache.ListKey(ctx, query, filter)
+	if err != nil {
+		return r.next.FindAll(ctx, query, filter)
+	}
+	list := &models.ProductsList{}
+	if r.cache.Get(ctx, key, list) {
+		return list, nil
+	}
+
+	list, err = r.next.FindAll(ctx, query, filter)
+	if err != nil {
+		return nil, err
+	}
+	r.cache.Set(ctx, key, list, r.listTTL)
+	return list, nil
+}
+
+func (r *productCacheRepo) Delete(ctx context.Context, id int) error {
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.cache.Invalidate(ctx, strconv.Itoa(id))
+	return nil
+}
+
+func (r *productCacheRepo) Search(ctx context.Context, search string, query *pagination.PageQuery) (*models.ProductsSearchList, error) {
+	return r.next.Search(ctx, search, query)
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/pkg/cache/cachetest"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Warnf(template string, args ...interface{}) {}
+
+// fakeProducts product repository counting the reads that reach it
+type fakeProducts struct {
+	products map[int]models.Product
+	gets     int
+	finds    int
+	// onGet runs after a product is read, before it is returned
+	onGet func()
+}
+
+func (r *fakeProducts) Create(ctx context.Context, product *models.Product) error {
+	for id := range r.products {
+		if id >= product.ID {
+			product.ID = id + 1
+		}
+	}
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *fakeProducts) Update(ctx context.Context, product *models.Product) error {
+	if _, ok := r.products[product.ID]; !ok {
+		return pgx.ErrNoRows
+	}
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *fakeProducts) GetByID(ctx context.Context, id string) (*models.Product, error) {
+	r.gets++
+	productID, _ := strconv.Atoi(id)
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	if r.onGet != nil {
+		r.onGet()
+	}
+	return &product, nil
+}
+
+func (r *fakeProducts) FindAll(ctx context.Context, query *pagination.Query, filter *models.ProductFilter) (*models.ProductsList, error) {
+	r.finds++
+	list := &models.ProductsList{Products: []models.Product{}}
+	for _, product := range r.products {
+		list.Products = append(list.Products, product)
+	}
+	sort.Slice(list.Products, func(i, j int) bool { return list.Products[i].ID < list.Products[j].ID })
+	return list, nil
+}
+
+func (r *fakeProducts) Delete(ctx context.Context, id int) error {
+	if _, ok := r.products[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(r.products, id)
+	return nil
+}
+
+func (r *fakeProducts) Search(ctx context.Context, search string, query *pagination.PageQuery) (*models.ProductsSearchList, error) {
+	return &models.ProductsSearchList{}, nil
+}
+
+func newCachedProducts() (*fakeProducts, *productCacheRepo, *cachetest.Client) {
+	next := &fakeProducts{products: map[int]models.Product{
+		1: {ID: 1, Name: "tea", Price: 100},
+		2: {ID: 2, Name: "cup", Price: 300},
+	}}
+	client := cachetest.NewClient()
+	repo := NewProductCacheRepo(next, client, nopLogger{}, time.Minute, time.Second).(*productCacheRepo)
+	return next, repo, client
+}
+
+func TestProductCacheGetByID(t *testing.T) {
+	next, repo, client := newCachedProducts()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		product, err := repo.GetByID(ctx, "1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if product.Name != "tea" {
+			t.Fatalf("GetByID() = %+v, want tea", product)
+		}
+	}
+	if next.gets != 1 {
+		t.Errorf("next GetByID called %d times, want 1", next.gets)
+	}
+	if ttl := client.TTL("products:1"); ttl != time.Minute {
+		t.Errorf("product ttl = %v, want %v", ttl, time.Minute)
+	}
+
+	if _, err := repo.GetByID(ctx, "42"); err == nil {
+		t.Error("GetByID() of a missing product error = nil")
+	}
+	if _, ok := client.Value("products:42"); ok {
+		t.Error("missing product is cached")
+	}
+}
+
+func TestProductCacheInvalidation(t *testing.T) {
+	query := &pagination.Query{Sort: "id", Limit: 10}
+	filter := &models.ProductFilter{}
+
+	tests := []struct {
+		name   string
+		change func(ctx context.Context, repo *productCacheRepo) error
+		// the product 1 read after the change, nil when it is deleted
+		want *models.Product
+	}{
+		{
+			name: "create",
+			change: func(ctx context.Context, repo *productCacheRepo) error {
+				return repo.Create(ctx, &models.Product{Name: "pot", Price: 900})
+			},
+			want: &models.Product{ID: 1, Name: "tea", Price: 100},
+		},
+		{
+			name: "update",
+			change: func(ctx context.Context, repo *productCacheRepo) error {
+				return repo.Update(ctx, &models.Product{ID: 1, Name: "green tea", Price: 150})
+			},
+			want: &models.Product{ID: 1, Name: "green tea", Price: 150},
+		},
+		{
+			name: "delete",
+			change: func(ctx context.Context, repo *productCacheRepo) error {
+				return repo.Delete(ctx, 1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, repo, _ := newCachedProducts()
+			ctx := context.Background()
+
+			if _, err := repo.GetByID(ctx, "1"); err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			before, err := repo.FindAll(ctx, query, filter)
+			if err != nil {
+				t.Fatalf("FindAll() error = %v", err)
+			}
+			if _, err := repo.FindAll(ctx, query, filter); err != nil {
+				t.Fatalf("FindAll() error = %v", err)
+			}
+			if next.finds != 1 {
+				t.Fatalf("next FindAll called %d times before the change, want 1", next.finds)
+			}
+
+			if err := tt.change(ctx, repo); err != nil {
+				t.Fatalf("change error = %v", err)
+			}
+
+			after, err := repo.FindAll(ctx, query, filter)
+			if err != nil {
+				t.Fatalf("FindAll() error = %v", err)
+			}
+			if next.finds != 2 {
+				t.Errorf("next FindAll called %d times, want the cached list dropped", next.finds)
+			}
+			if len(after.Products) == len(before.Products) && after.Products[0] == before.Products[0] {
+				t.Errorf("FindAll() = %+v after the change, want the new list", after.Products)
+			}
+
+			product, err := repo.GetByID(ctx, "1")
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("GetByID() = %+v after delete, want error", product)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if *product != *tt.want {
+				t.Errorf("GetByID() = %+v, want %+v", product, tt.want)
+			}
+		})
+	}
+}
+
+func TestProductCacheSkipsValueReadBeforeInvalidate(t *testing.T) {
+	next, repo, client := newCachedProducts()
+	ctx := context.Background()
+
+	// an update is committed and invalidates the cache while the old product is being read
+	next.onGet = func() {
+		next.onGet = nil
+		if err := repo.Update(ctx, &models.Product{ID: 1, Name: "green tea", Price: 150}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+	if _, err := repo.GetByID(ctx, "1"); err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if v, ok := client.Value("products:1"); ok {
+		t.Fatalf("product read before the update is cached: %s", v)
+	}
+
+	product, err := repo.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if product.Name != "green tea" {
+		t.Errorf("GetByID() = %+v, want the updated product", product)
+	}
+}
@@ -2,13 +2,29 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"strconv"
+
 	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/outbox"
+	outboxRepo "github.com/Lidne/praktika_MAI/internal/outbox/repository"
 	"github.com/Lidne/praktika_MAI/internal/product"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
 
 	"github.com/Lidne/praktika_MAI/pkg/postgres"
 )
 
+// productDocument weighted full-text document of a product, name ranks above description
+const productDocument = `setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', description), 'B')`
+
+var productSortColumns = map[string]postgres.SortColumn{
+	"id":         {Column: "id", Type: "integer"},
+	"name":       {Column: "name", Type: "text"},
+	"price":      {Column: "price", Type: "integer"},
+	"updated_at": {Column: "updatedat", Type: "timestamp"},
+}
+
 // productRepo
 type productRepo struct {
 	client postgres.Client
@@ -19,64 +35,150 @@ func NewProductRepo(client postgres.Client) product.ProductRepository {
 	return &productRepo{client: client}
 }
 
-func (r *productRepo) Create(ctx context.Context, product *models.Product) error {
-	q := `INSERT INTO products (name, price) VALUES ($1, $2) returning id`
-	if err := r.client.QueryRow(ctx, q, product.Name, product.Price).Scan(&product.ID); err != nil {
-		fmt.Errorf(fmt.Sprintf("SQL Error. Falied to create product: %s", err.Error()))
-		return err
-	}
+func (r *productRepo) Create(ctx context.Context, product *models.Product) (err error) {
+	span, ctx := postgres.StartSpan(ctx, "productRepo.Create")
+	defer postgres.Finish(span, &err)
 
-	return nil
+	return pgx.BeginFunc(ctx, r.client, func(tx pgx.Tx) error {
+		q := `INSERT INTO products (name, description, price) VALUES ($1, $2, $3) returning id, updatedat`
+		if err := tx.QueryRow(ctx, q, product.Name, product.Description, product.Price).Scan(&product.ID, &product.CreatedAt); err != nil {
+			return errors.Wrap(err, "productRepo.Create.QueryRow")
+		}
+		return outboxRepo.Write(ctx, tx, outbox.AggregateProduct, strconv.Itoa(product.ID), outbox.EventCreated, product)
+	})
 }
 
-func (r *productRepo) Update(ctx context.Context, product *models.Product) error {
-	q := `UPDATE products SET name=$1, price=$2 WHERE id=$3`
-	_, err := r.client.Exec(ctx, q, &product.Name, &product.Price, &product.ID)
-	if err != nil {
-		fmt.Errorf("SQL Error. Failed to update product: %s", err.Error())
-		return err
-	}
-	return nil
+func (r *productRepo) Update(ctx context.Context, product *models.Product) (err error) {
+	span, ctx := postgres.StartSpan(ctx, "productRepo.Update")
+	defer postgres.Finish(span, &err)
+
+	return pgx.BeginFunc(ctx, r.client, func(tx pgx.Tx) error {
+		q := `UPDATE products SET name=$1, description=$2, price=$3 WHERE id=$4 returning updatedat`
+		if err := tx.QueryRow(ctx, q, &product.Name, &product.Description, &product.Price, &product.ID).Scan(&product.CreatedAt); err != nil {
+			return errors.Wrap(err, "productRepo.Update.QueryRow")
+		}
+		return outboxRepo.Write(ctx, tx, outbox.AggregateProduct, strconv.Itoa(product.ID), outbox.EventUpdated, product)
+	})
 }
 
-func (r *productRepo) GetByID(ctx context.Context, id string) (*models.Product, error) {
-	q := `SELECT id, name, price, updatedat FROM products WHERE id=$1`
+func (r *productRepo) GetByID(ctx context.Context, id string) (_ *models.Product, err error) {
+	span, ctx := postgres.StartSpan(ctx, "productRepo.GetByID")
+	defer postgres.Finish(span, &err)
+
+	q := `SELECT id, name, description, price, updatedat FROM products WHERE id=$1`
 	product := &models.Product{}
-	if err := r.client.QueryRow(ctx, q, id).Scan(&product.ID, &product.Name, &product.Price, &product.CreatedAt); err != nil {
-		fmt.Errorf("SQL Error. Failed to get product by ID: %s", err.Error())
-		return nil, err
+	if err := r.client.QueryRow(ctx, q, id).Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.CreatedAt); err != nil {
+		return nil, errors.Wrap(err, "productRepo.GetByID.QueryRow")
 	}
 	return product, nil
 }
 
-func (r *productRepo) FindAll(ctx context.Context) ([]models.Product, error) {
-	q := `SELECT id, name, price, updatedat FROM products`
-	rows, err := r.client.Query(ctx, q)
+func (r *productRepo) FindAll(ctx context.Context, query *pagination.Query, filter *models.ProductFilter) (_ *models.ProductsList, err error) {
+	span, ctx := postgres.StartSpan(ctx, "productRepo.FindAll")
+	defer postgres.Finish(span, &err)
+
+	col, ok := productSortColumns[query.Sort]
+	if !ok {
+		return nil, errors.Wrap(pagination.ErrInvalidSort, query.Sort)
+	}
+
+	b := &postgres.Builder{}
+	if filter.MinPrice != nil {
+		b.Where("price >= " + b.Arg(*filter.MinPrice))
+	}
+	if filter.MaxPrice != nil {
+		b.Where("price <= " + b.Arg(*filter.MaxPrice))
+	}
+	q := b.Keyset(`SELECT id, name, description, price, updatedat, `+col.Column+`::text FROM products`, col, query)
+
+	rows, err := r.client.Query(ctx, q, b.Args()...)
 	if err != nil {
-		fmt.Errorf("SQL Error. Failed to find all products: %s", err.Error())
-		return nil, err
+		return nil, errors.Wrap(err, "productRepo.FindAll.Query")
 	}
 	defer rows.Close()
 
-	products := []models.Product{}
+	list := &models.ProductsList{Products: []models.Product{}}
+	var sortKey string
 	for rows.Next() {
+		if len(list.Products) == query.Limit {
+			last := list.Products[len(list.Products)-1]
+			list.NextCursor = query.Next(sortKey, last.ID)
+			break
+		}
 		product := models.Product{}
-		if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.CreatedAt); err != nil {
-			fmt.Errorf("SQL Error. Failed to scan product: %s", err.Error())
-			return nil, err
+		if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.CreatedAt, &sortKey); err != nil {
+			return nil, errors.Wrap(err, "productRepo.FindAll.Scan")
 		}
-		products = append(products, product)
+		list.Products = append(list.Products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "productRepo.FindAll.rows")
 	}
 
-	return products, nil
+	return list, nil
 }
 
-func (r *productRepo) Delete(ctx context.Context, id int) error {
-	q := `DELETE FROM products WHERE id=$1`
-	_, err := r.client.Exec(ctx, q, id)
+func (r *productRepo) Delete(ctx context.Context, id int) (err error) {
+	span, ctx := postgres.StartSpan(ctx, "productRepo.Delete")
+	defer postgres.Finish(span, &err)
+
+	return pgx.BeginFunc(ctx, r.client, func(tx pgx.Tx) error {
+		q := `DELETE FROM products WHERE id=$1`
+		tag, err := tx.Exec(ctx, q, id)
+		if err != nil {
+			return errors.Wrap(err, "productRepo.Delete.Exec")
+		}
+		if tag.RowsAffected() == 0 {
+			return errors.Wrap(pgx.ErrNoRows, "productRepo.Delete")
+		}
+		return outboxRepo.Write(ctx, tx, outbox.AggregateProduct, strconv.Itoa(id), outbox.EventDeleted, models.Product{ID: id})
+	})
+}
+
+// Search full-text search over product name and description ordered by rank
+func (r *productRepo) Search(ctx context.Context, search string, query *pagination.PageQuery) (_ *models.ProductsSearchList, err error) {
+	span, ctx := postgres.StartSpan(ctx, "productRepo.Search")
+	defer postgres.Finish(span, &err)
+
+	var totalCount int64
+	countQuery := `SELECT count(*) FROM products WHERE ` + productDocument + ` @@ websearch_to_tsquery('simple', $1)`
+	if err := r.client.QueryRow(ctx, countQuery, search).Scan(&totalCount); err != nil {
+		return nil, errors.Wrap(err, "productRepo.Search.QueryRow")
+	}
+
+	list := &models.ProductsSearchList{
+		TotalCount: totalCount,
+		TotalPages: query.TotalPages(totalCount),
+		Page:       query.Page,
+		Size:       query.Size,
+		HasMore:    query.HasMore(totalCount),
+		Products:   []models.Product{},
+	}
+	if totalCount == 0 || query.Offset() >= totalCount {
+		return list, nil
+	}
+
+	q := `SELECT id, name, description, price, updatedat
+		FROM products, websearch_to_tsquery('simple', $1) query
+		WHERE ` + productDocument + ` @@ query
+		ORDER BY ts_rank(` + productDocument + `, query) DESC, id
+		LIMIT $2 OFFSET $3`
+	rows, err := r.client.Query(ctx, q, search, query.Size, query.Offset())
 	if err != nil {
-		fmt.Errorf("SQL Error. Failed to delete product: %s", err.Error())
-		return err
+		return nil, errors.Wrap(err, "productRepo.Search.Query")
 	}
-	return nil
+	defer rows.Close()
+
+	for rows.Next() {
+		product := models.Product{}
+		if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "productRepo.Search.Scan")
+		}
+		list.Products = append(list.Products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "productRepo.Search.rows")
+	}
+
+	return list, nil
 }
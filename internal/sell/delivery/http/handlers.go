@@ -0,0 +1,299 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/sell"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	httpUtils "github.com/Lidne/praktika_MAI/pkg/http_utils"
+)
+
+type handlers struct {
+	sales    sell.SellRepository
+	validate *validator.Validate
+}
+
+// getSales godoc
+//
+//	@Summary		Get Sales
+//	@Tags			Sales
+//	@Description	Get a page of sales
+//	@ID				get-sales
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit		query	int		false	"Page size"
+//	@Param			after		query	string	false	"Cursor returned as next_cursor"
+//	@Param			sort		query	string	false	"Sort field: id, user_id, product_id, updated_at, prefix with - for descending order"
+//	@Param			user_id		query	int		false	"Buyer ID"
+//	@Param			product_id	query	int		false	"Product ID"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/sales [get]
+func (h *handlers) getSales(c echo.Context) error {
+	query, err := httpUtils.PaginationQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	filter := &models.SellFilter{}
+	if filter.UserId, err = httpUtils.QueryInt(c, "user_id"); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if filter.ProductId, err = httpUtils.QueryInt(c, "product_id"); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if !auth.Allowed(c, auth.PermSalesRead) {
+		userId := auth.UserID(c)
+		if filter.UserId != nil && *filter.UserId != userId {
+			return httpUtils.ForbiddenResponse(c)
+		}
+		filter.UserId = &userId
+	}
+
+	list, err := h.sales.FindAll(c.Request().Context(), query, filter)
+	if err != nil {
+		return httpUtils.ListErrorResponse(c, err)
+	}
+	res := []echo.Map{}
+	for _, sll := range list.Sells {
+		res = append(res, echo.Map{
+			"id":         sll.ID,
+			"updated_at": sll.UpdatedAt,
+			"user_id":    sll.UserId,
+			"product_id": sll.ProductId,
+			"price":      sll.Price,
+		})
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data":        res,
+		"next_cursor": list.NextCursor,
+	})
+}
+
+// getSellById godoc
+//
+//	@Summary		Get Sale By ID
+//	@Tags			Sales
+//	@Description	Get a sale by ID
+//	@ID				get-sale-by-id
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	string	true	"Sale ID"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		404	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/sales/{id} [get]
+func (h *handlers) getSellById(c echo.Context) error {
+	sellId := c.Param("id")
+	sll, err := h.sales.GetByID(c.Request().Context(), sellId)
+	if err != nil {
+		return err
+	}
+	if !auth.CanAccess(c, auth.PermSalesRead, sll.UserId) {
+		return httpUtils.ForbiddenResponse(c)
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": echo.Map{
+			"id":         sll.ID,
+			"updated_at": sll.UpdatedAt,
+			"user_id":    sll.UserId,
+			"product_id": sll.ProductId,
+			"price":      sll.Price,
+		},
+	})
+}
+
+type sellRequest struct {
+	UserId    int `json:"user_id" validate:"required,gt=0"`
+	ProductId int `json:"product_id" validate:"required,gt=0"`
+}
+
+// createSell godoc
+//
+//	@Summary		Create Sale
+//	@Tags			Sales
+//	@Description	Register a sale of a product to a user
+//	@ID				create-sale
+//	@Accept			json
+//	@Produce		json
+//	@Param			sale	body	sellRequest	true	"Sale"
+//	@Success		201	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		422	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/sales [post]
+func (h *handlers) createSell(c echo.Context) error {
+	var req sellRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if err := h.validate.StructCtx(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	if !auth.CanAccess(c, auth.PermSalesWrite, req.UserId) {
+		return httpUtils.ForbiddenResponse(c)
+	}
+
+	sll := &models.Sell{UserId: req.UserId, ProductId: req.ProductId}
+	if err := h.sales.Create(c.Request().Context(), sll); err != nil {
+		if errors.Is(err, sell.ErrUserNotFound) || errors.Is(err, sell.ErrProductNotFound) {
+			return c.JSON(http.StatusUnprocessableEntity, httpErrors.NewRestError(http.StatusUnprocessableEntity, err.Error(), nil))
+		}
+		return err
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"data": echo.Map{
+			"id":         sll.ID,
+			"updated_at": sll.UpdatedAt,
+			"user_id":    sll.UserId,
+			"product_id": sll.ProductId,
+			"price":      sll.Price,
+		},
+	})
+}
+
+type Interval struct {
+	Interval string `query:"interval"`
+}
+
+// getSalesDate godoc
+//
+//	@Summary		Get Sales by Interval
+//	@Tags			Sales
+//	@Description	Get sales data filtered by a time interval
+//	@ID				get-sales-date
+//	@Accept			json
+//	@Produce		json
+//	@Param			interval	query	string	true	"Postgres interval before now, e.g. 7 days"
+//	@Param			limit		query	int		false	"Page size"
+//	@Param			after		query	string	false	"Cursor returned as next_cursor"
+//	@Param			sort		query	string	false	"Sort field: id, user_id, product_id, updated_at, prefix with - for descending order"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/sales/interval [get]
+func (h *handlers) getSalesDate(c echo.Context) error {
+	var interval Interval
+	err := c.Bind(&interval)
+	if err != nil || interval.Interval == "" {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("interval is required"))
+	}
+	query, err := httpUtils.PaginationQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+
+	list, err := h.sales.SelectByTime(c.Request().Context(), interval.Interval, query)
+	if err != nil {
+		return httpUtils.ListErrorResponse(c, err)
+	}
+	res := []echo.Map{}
+	for _, sll := range list.Sells {
+		res = append(res, echo.Map{
+			"id":         sll.ID,
+			"updated_at": sll.UpdatedAt,
+			"user_id":    sll.UserId,
+			"product_id": sll.ProductId,
+			"price":      sll.Price,
+		})
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data":        res,
+		"next_cursor": list.NextCursor,
+	})
+}
+
+const (
+	timeSeriesDateLayout = "2006-01-02"
+	maxTimeSeriesBuckets = 5000
+)
+
+// minBucketDuration shortest possible duration of each bucket
+var minBucketDuration = map[string]time.Duration{
+	sell.BucketHour:  time.Hour,
+	sell.BucketDay:   23 * time.Hour,
+	sell.BucketWeek:  7*24*time.Hour - time.Hour,
+	sell.BucketMonth: 28*24*time.Hour - time.Hour,
+}
+
+// parseTimeSeriesBound parse RFC3339 time or YYYY-MM-DD date, dates are midnight in loc
+func parseTimeSeriesBound(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(timeSeriesDateLayout, value, loc)
+}
+
+// getSalesTimeSeries godoc
+//
+//	@Summary		Get Sales Time Series
+//	@Tags			Sales
+//	@Description	Sales count and revenue per hour, day, week or month of the given time zone, buckets without sales have zero values
+//	@ID				get-sales-time-series
+//	@Accept			json
+//	@Produce		json
+//	@Param			from	query	string	true	"Period start, RFC3339 time or YYYY-MM-DD date"
+//	@Param			to		query	string	true	"Period end exclusive, RFC3339 time or YYYY-MM-DD date"
+//	@Param			bucket	query	string	false	"hour, day, week or month"	default(day)
+//	@Param			tz		query	string	false	"IANA time zone"			default(UTC)
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/sales/timeseries [get]
+func (h *handlers) getSalesTimeSeries(c echo.Context) error {
+	filter := &models.SalesTimeSeriesFilter{Bucket: c.QueryParam("bucket"), Location: time.UTC}
+	if filter.Bucket == "" {
+		filter.Bucket = sell.BucketDay
+	}
+	minDuration, ok := minBucketDuration[filter.Bucket]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("bucket must be one of hour, day, week, month"))
+	}
+	if tz := c.QueryParam("tz"); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("unknown time zone "+tz))
+		}
+		filter.Location = loc
+	}
+
+	var err error
+	if filter.From, err = parseTimeSeriesBound(c.QueryParam("from"), filter.Location); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid from, expected RFC3339 time or YYYY-MM-DD date"))
+	}
+	if filter.To, err = parseTimeSeriesBound(c.QueryParam("to"), filter.Location); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid to, expected RFC3339 time or YYYY-MM-DD date"))
+	}
+	if !filter.From.Before(filter.To) {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("from must be before to"))
+	}
+	if filter.To.Sub(filter.From)/minDuration > maxTimeSeriesBuckets {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("range is too long for the bucket"))
+	}
+
+	series, err := h.sales.TimeSeries(c.Request().Context(), filter)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": series,
+	})
+}
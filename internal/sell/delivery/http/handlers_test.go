@@ -0,0 +1,180 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/middlewares"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/sell"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Errorf(template string, args ...interface{}) {}
+
+// fakeSales sales repository knowing users 1, 2 and product 10 priced 250
+type fakeSales struct {
+	sell.SellRepository
+	created []models.Sell
+}
+
+func (r *fakeSales) Create(ctx context.Context, sll *models.Sell) error {
+	if sll.UserId != 1 && sll.UserId != 2 {
+		return errors.Wrap(sell.ErrUserNotFound, "salesRepo.Create")
+	}
+	if sll.ProductId != 10 {
+		return errors.Wrap(sell.ErrProductNotFound, "salesRepo.Create")
+	}
+	sll.ID = len(r.created) + 1
+	sll.Price = 250
+	r.created = append(r.created, *sll)
+	return nil
+}
+
+func (r *fakeSales) FindAll(ctx context.Context, query *pagination.Query, filter *models.SellFilter) (*models.SellsList, error) {
+	list := &models.SellsList{}
+	for _, sll := range r.created {
+		if filter.UserId == nil || *filter.UserId == sll.UserId {
+			list.Sells = append(list.Sells, sll)
+		}
+	}
+	return list, nil
+}
+
+var (
+	admin = &auth.Claims{ID: 1, Login: "admin", Role: auth.RoleAdmin}
+	buyer = &auth.Claims{ID: 2, Login: "bob", Role: auth.RoleUser}
+)
+
+func TestCreateSell(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *auth.Claims
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "buyer buys for self",
+			claims:     buyer,
+			body:       `{"user_id": 2, "product_id": 10}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `"price":250`,
+		},
+		{
+			name:       "admin sells to a user",
+			claims:     admin,
+			body:       `{"user_id": 2, "product_id": 10}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   `"user_id":2`,
+		},
+		{
+			name:       "buyer buys for another user",
+			claims:     buyer,
+			body:       `{"user_id": 1, "product_id": 10}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "unknown user",
+			claims:     admin,
+			body:       `{"user_id": 42, "product_id": 10}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   sell.ErrUserNotFound.Error(),
+		},
+		{
+			name:       "unknown product",
+			claims:     buyer,
+			body:       `{"user_id": 2, "product_id": 42}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   sell.ErrProductNotFound.Error(),
+		},
+		{
+			name:       "missing product",
+			claims:     buyer,
+			body:       `{"user_id": 2}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "anonymous",
+			body:       `{"user_id": 2, "product_id": 10}`,
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sales := &fakeSales{}
+			e := newServer(sales, tt.claims)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/sales", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body %s does not contain %s", rec.Body, tt.wantBody)
+			}
+			if created := tt.wantStatus == http.StatusCreated; created != (len(sales.created) == 1) {
+				t.Errorf("created %d sales", len(sales.created))
+			}
+		})
+	}
+}
+
+func TestGetSalesScopedToBuyer(t *testing.T) {
+	sales := &fakeSales{created: []models.Sell{
+		{ID: 1, UserId: 1, ProductId: 10, Price: 250},
+		{ID: 2, UserId: 2, ProductId: 10, Price: 250},
+	}}
+	e := newServer(sales, buyer)
+
+	for target, wantStatus := range map[string]int{
+		"/api/sales":           http.StatusOK,
+		"/api/sales?user_id=2": http.StatusOK,
+		"/api/sales?user_id=1": http.StatusForbidden,
+	} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != wantStatus {
+			t.Fatalf("%s: status = %d, want %d: %s", target, rec.Code, wantStatus, rec.Body)
+		}
+		if wantStatus == http.StatusOK && (!strings.Contains(rec.Body.String(), `"id":2`) || strings.Contains(rec.Body.String(), `"id":1`)) {
+			t.Errorf("%s: body %s, want only sale 2", target, rec.Body)
+		}
+	}
+}
+
+// newServer echo server with sales routes and the requests authenticated as claims
+func newServer(sales sell.SellRepository, claims *auth.Claims) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = httpErrors.NewHTTPErrorHandler(nopLogger{})
+	api := e.Group("/api", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if claims != nil {
+				copied := *claims
+				auth.SetClaims(c, &copied)
+			}
+			return next(c)
+		}
+	})
+	NewRouter(api, sales, validator.New(), middlewares.NewMiddlewareManager(nopLogger{}, &config.Config{}, nil, nil))
+	return e
+}
@@ -0,0 +1,21 @@
+package http
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/middlewares"
+	"github.com/Lidne/praktika_MAI/internal/sell"
+)
+
+// NewRouter register sales routes
+func NewRouter(e *echo.Group, sales sell.SellRepository, validate *validator.Validate, mw middlewares.MiddlewareManager) {
+	h := &handlers{sales: sales, validate: validate}
+	salesGroup := e.Group("/sales")
+	salesGroup.GET("", h.getSales)
+	salesGroup.GET("/:id", h.getSellById)
+	salesGroup.POST("", h.createSell)
+	salesGroup.GET("/interval", h.getSalesDate, mw.RequirePermission(auth.PermSalesRead))
+	salesGroup.GET("/timeseries", h.getSalesTimeSeries, mw.RequirePermission(auth.PermSalesRead))
+}
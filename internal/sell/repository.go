@@ -2,7 +2,23 @@ package sell
 
 import (
 	"context"
+	"time"
+
 	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+	"github.com/pkg/errors"
+)
+
+const (
+	BucketHour  = "hour"
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrProductNotFound = errors.New("product not found")
 )
 
 // SellRepository Sell
@@ -10,7 +26,49 @@ type SellRepository interface {
 	Create(ctx context.Context, product *models.Sell) error
 	Update(ctx context.Context, product *models.Sell) error
 	GetByID(ctx context.Context, id string) (*models.Sell, error)
-	FindAll(ctx context.Context) ([]models.Sell, error)
+	FindAll(ctx context.Context, query *pagination.Query, filter *models.SellFilter) (*models.SellsList, error)
 	Delete(ctx context.Context, id int) error
-	SelectByTime(ctx context.Context, time string) ([]models.Sell, error)
+	SelectByTime(ctx context.Context, interval string, query *pagination.Query) (*models.SellsList, error)
+	TimeSeries(ctx context.Context, filter *models.SalesTimeSeriesFilter) ([]models.SalesBucket, error)
+}
+
+// BucketStart start of the bucket containing t in the location of t, weeks start on Monday
+func BucketStart(t time.Time, bucket string) time.Time {
+	y, m, d := t.Date()
+	switch bucket {
+	case BucketHour:
+		// wall clock minutes are cut off instead of calling time.Date, which is ambiguous in the hour repeated on a DST change
+		return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second - time.Duration(t.Nanosecond()))
+	case BucketWeek:
+		return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case BucketMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// NextBucket start of the bucket following the one starting at t
+func NextBucket(t time.Time, bucket string) time.Time {
+	switch bucket {
+	case BucketHour:
+		return t.Add(time.Hour)
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// FillSeries buckets of the filter period in order, totals are looked up in byStart by unix time of the
+// bucket start and buckets without sales are zero
+func FillSeries(filter *models.SalesTimeSeriesFilter, byStart map[int64]models.SalesBucket) []models.SalesBucket {
+	series := []models.SalesBucket{}
+	for start := BucketStart(filter.From.In(filter.Location), filter.Bucket); start.Before(filter.To); start = NextBucket(start, filter.Bucket) {
+		b := byStart[start.Unix()]
+		series = append(series, models.SalesBucket{Start: start, Count: b.Count, Revenue: b.Revenue})
+	}
+	return series
 }
@@ -2,12 +2,25 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"strconv"
+
 	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/outbox"
+	outboxRepo "github.com/Lidne/praktika_MAI/internal/outbox/repository"
 	"github.com/Lidne/praktika_MAI/internal/sell"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
 	"github.com/Lidne/praktika_MAI/pkg/postgres"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
 )
 
+var sellSortColumns = map[string]postgres.SortColumn{
+	"id":         {Column: "id", Type: "integer"},
+	"user_id":    {Column: "user_id", Type: "integer"},
+	"product_id": {Column: "product_id", Type: "integer"},
+	"updated_at": {Column: "updatedat", Type: "timestamp"},
+}
+
 // sellRepo
 type sellRepo struct {
 	client postgres.Client
@@ -18,86 +31,171 @@ func NewSellRepo(client postgres.Client) sell.SellRepository {
 	return &sellRepo{client: client}
 }
 
-func (r *sellRepo) Create(ctx context.Context, sell *models.Sell) error {
-	q := `INSERT INTO bargains (user_id, product_id) VALUES ($1, $2) returning id`
-	if err := r.client.QueryRow(ctx, q, sell.UserId, sell.ProductId).Scan(&sell.ID); err != nil {
-		fmt.Errorf(fmt.Sprintf("SQL Error. Falied to create sell: %s", err.Error()))
-		return err
+// Create checks that the buyer and the product exist and inserts the sell with the current product price in one transaction
+func (r *sellRepo) Create(ctx context.Context, sll *models.Sell) (err error) {
+	span, ctx := postgres.StartSpan(ctx, "sellRepo.Create")
+	defer postgres.Finish(span, &err)
+
+	tx, err := r.client.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "sellRepo.Create.Begin")
 	}
+	defer tx.Rollback(ctx)
 
-	return nil
-}
+	var id int
+	if err := tx.QueryRow(ctx, `SELECT id FROM users WHERE id=$1 FOR SHARE`, sll.UserId).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sell.ErrUserNotFound
+		}
+		return errors.Wrap(err, "sellRepo.Create.QueryRow.users")
+	}
+	if err := tx.QueryRow(ctx, `SELECT price FROM products WHERE id=$1 FOR SHARE`, sll.ProductId).Scan(&sll.Price); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return sell.ErrProductNotFound
+		}
+		return errors.Wrap(err, "sellRepo.Create.QueryRow.products")
+	}
 
-func (r *sellRepo) Update(ctx context.Context, sell *models.Sell) error {
-	q := `UPDATE bargains SET user_id=$1, product_id=$2 WHERE id=$3`
-	_, err := r.client.Exec(ctx, q, &sell.UserId, &sell.ProductId, &sell.ID)
-	if err != nil {
-		fmt.Errorf("SQL Error. Failed to update sell: %s", err.Error())
+	q := `INSERT INTO bargains (user_id, product_id, price) VALUES ($1, $2, $3) returning id, updatedat`
+	if err := tx.QueryRow(ctx, q, sll.UserId, sll.ProductId, sll.Price).Scan(&sll.ID, &sll.UpdatedAt); err != nil {
+		return errors.Wrap(err, "sellRepo.Create.QueryRow")
+	}
+	if err := outboxRepo.Write(ctx, tx, outbox.AggregateSell, strconv.Itoa(sll.ID), outbox.EventCreated, sll); err != nil {
 		return err
 	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return errors.Wrap(err, "sellRepo.Create.Commit")
+	}
 	return nil
 }
 
-func (r *sellRepo) GetByID(ctx context.Context, id string) (*models.Sell, error) {
-	q := `SELECT id, user_id, product_id, updatedat FROM bargains WHERE id=$1`
+// Update keeps the sell price unless the product changes, then the price of the new product is taken
+func (r *sellRepo) Update(ctx context.Context, sll *models.Sell) (err error) {
+	span, ctx := postgres.StartSpan(ctx, "sellRepo.Update")
+	defer postgres.Finish(span, &err)
+
+	return pgx.BeginFunc(ctx, r.client, func(tx pgx.Tx) error {
+		q := `UPDATE bargains SET user_id=$1, product_id=$2,
+			price=CASE WHEN product_id=$2 THEN price ELSE (SELECT price FROM products WHERE id=$2) END
+			WHERE id=$3 returning price, updatedat`
+		if err := tx.QueryRow(ctx, q, &sll.UserId, &sll.ProductId, &sll.ID).Scan(&sll.Price, &sll.UpdatedAt); err != nil {
+			return errors.Wrap(err, "sellRepo.Update.QueryRow")
+		}
+		return outboxRepo.Write(ctx, tx, outbox.AggregateSell, strconv.Itoa(sll.ID), outbox.EventUpdated, sll)
+	})
+}
+
+func (r *sellRepo) GetByID(ctx context.Context, id string) (_ *models.Sell, err error) {
+	span, ctx := postgres.StartSpan(ctx, "sellRepo.GetByID")
+	defer postgres.Finish(span, &err)
+
+	q := `SELECT id, user_id, product_id, price, updatedat FROM bargains WHERE id=$1`
 	sell := &models.Sell{}
-	if err := r.client.QueryRow(ctx, q, id).Scan(&sell.ID, &sell.UserId, &sell.ProductId, &sell.UpdatedAt); err != nil {
-		fmt.Errorf("SQL Error. Failed to get sell by ID: %s", err.Error())
-		return nil, err
+	if err := r.client.QueryRow(ctx, q, id).Scan(&sell.ID, &sell.UserId, &sell.ProductId, &sell.Price, &sell.UpdatedAt); err != nil {
+		return nil, errors.Wrap(err, "sellRepo.GetByID.QueryRow")
 	}
 	return sell, nil
 }
 
-func (r *sellRepo) FindAll(ctx context.Context) ([]models.Sell, error) {
-	q := `SELECT id, user_id, product_id, updatedat FROM bargains`
-	rows, err := r.client.Query(ctx, q)
+func (r *sellRepo) FindAll(ctx context.Context, query *pagination.Query, filter *models.SellFilter) (_ *models.SellsList, err error) {
+	span, ctx := postgres.StartSpan(ctx, "sellRepo.FindAll")
+	defer postgres.Finish(span, &err)
+
+	col, ok := sellSortColumns[query.Sort]
+	if !ok {
+		return nil, errors.Wrap(pagination.ErrInvalidSort, query.Sort)
+	}
+
+	b := &postgres.Builder{}
+	if filter.UserId != nil {
+		b.Where("user_id = " + b.Arg(*filter.UserId))
+	}
+	if filter.ProductId != nil {
+		b.Where("product_id = " + b.Arg(*filter.ProductId))
+	}
+	if filter.Interval != nil {
+		b.Where("updatedat >= NOW() - " + b.Arg(*filter.Interval) + "::interval")
+	}
+	q := b.Keyset(`SELECT id, user_id, product_id, price, updatedat, `+col.Column+`::text FROM bargains`, col, query)
+
+	rows, err := r.client.Query(ctx, q, b.Args()...)
 	if err != nil {
-		fmt.Errorf("SQL Error. Failed to find all sells: %s", err.Error())
-		return nil, err
+		return nil, errors.Wrap(err, "sellRepo.FindAll.Query")
 	}
 	defer rows.Close()
 
-	sells := []models.Sell{}
+	list := &models.SellsList{Sells: []models.Sell{}}
+	var sortKey string
 	for rows.Next() {
-		product := models.Sell{}
-		if err := rows.Scan(&product.ID, &product.UserId, &product.ProductId, &product.UpdatedAt); err != nil {
-			fmt.Errorf("SQL Error. Failed to scan sell: %s", err.Error())
-			return nil, err
+		if len(list.Sells) == query.Limit {
+			last := list.Sells[len(list.Sells)-1]
+			list.NextCursor = query.Next(sortKey, last.ID)
+			break
+		}
+		sll := models.Sell{}
+		if err := rows.Scan(&sll.ID, &sll.UserId, &sll.ProductId, &sll.Price, &sll.UpdatedAt, &sortKey); err != nil {
+			return nil, errors.Wrap(err, "sellRepo.FindAll.Scan")
 		}
-		sells = append(sells, product)
+		list.Sells = append(list.Sells, sll)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "sellRepo.FindAll.rows")
 	}
 
-	return sells, nil
+	return list, nil
 }
 
-func (r *sellRepo) Delete(ctx context.Context, id int) error {
-	q := `DELETE FROM bargains WHERE id=$1`
-	_, err := r.client.Exec(ctx, q, id)
-	if err != nil {
-		fmt.Errorf("SQL Error. Failed to delete sell: %s", err.Error())
-		return err
-	}
-	return nil
+func (r *sellRepo) Delete(ctx context.Context, id int) (err error) {
+	span, ctx := postgres.StartSpan(ctx, "sellRepo.Delete")
+	defer postgres.Finish(span, &err)
+
+	return pgx.BeginFunc(ctx, r.client, func(tx pgx.Tx) error {
+		q := `DELETE FROM bargains WHERE id=$1`
+		tag, err := tx.Exec(ctx, q, id)
+		if err != nil {
+			return errors.Wrap(err, "sellRepo.Delete.Exec")
+		}
+		if tag.RowsAffected() == 0 {
+			return errors.Wrap(pgx.ErrNoRows, "sellRepo.Delete")
+		}
+		return outboxRepo.Write(ctx, tx, outbox.AggregateSell, strconv.Itoa(id), outbox.EventDeleted, models.Sell{ID: id})
+	})
+}
+
+// SelectByTime page of sales updated within the interval before now
+func (r *sellRepo) SelectByTime(ctx context.Context, interval string, query *pagination.Query) (*models.SellsList, error) {
+	return r.FindAll(ctx, query, &models.SellFilter{Interval: &interval})
 }
 
-func (r *sellRepo) SelectByTime(ctx context.Context, time string) ([]models.Sell, error) {
-	q := `SELECT * FROM bargains WHERE updatedat >= NOW() - INTERVAL $1`
-	rows, err := r.client.Query(ctx, q, time)
+// TimeSeries sales count and revenue per bucket, buckets without sales are filled with zeros.
+// updatedat holds UTC time, it is truncated in the filter location into an instant, so that the two
+// hours repeated on a DST change stay separate buckets
+func (r *sellRepo) TimeSeries(ctx context.Context, filter *models.SalesTimeSeriesFilter) (_ []models.SalesBucket, err error) {
+	span, ctx := postgres.StartSpan(ctx, "sellRepo.TimeSeries")
+	defer postgres.Finish(span, &err)
+
+	q := `SELECT date_trunc($1, b.updatedat AT TIME ZONE 'UTC', $2) AS bucket, count(*), coalesce(sum(b.price), 0)
+		FROM bargains b
+		WHERE b.updatedat >= $3 AND b.updatedat < $4
+		GROUP BY bucket`
+	rows, err := r.client.Query(ctx, q, filter.Bucket, filter.Location.String(), filter.From.UTC(), filter.To.UTC())
 	if err != nil {
-		fmt.Errorf("SQL Error. Failed to find all sells: %s", err.Error())
-		return nil, err
+		return nil, errors.Wrap(err, "sellRepo.TimeSeries.Query")
 	}
 	defer rows.Close()
 
-	sells := []models.Sell{}
+	byStart := map[int64]models.SalesBucket{}
 	for rows.Next() {
-		sell := models.Sell{}
-		if err := rows.Scan(&sell.ID, &sell.UserId, &sell.ProductId, &sell.UpdatedAt); err != nil {
-			fmt.Errorf("SQL Error. Failed to scan sell: %s", err.Error())
-			return nil, err
+		var b models.SalesBucket
+		if err := rows.Scan(&b.Start, &b.Count, &b.Revenue); err != nil {
+			return nil, errors.Wrap(err, "sellRepo.TimeSeries.Scan")
 		}
-		sells = append(sells, sell)
+		byStart[b.Start.Unix()] = b
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "sellRepo.TimeSeries.rows")
 	}
 
-	return sells, nil
+	return sell.FillSeries(filter, byStart), nil
 }
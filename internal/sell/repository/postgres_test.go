@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/sell"
+	"github.com/Lidne/praktika_MAI/pkg/postgres/postgrestest"
+)
+
+func TestCreateKeepsPrice(t *testing.T) {
+	pool := postgrestest.New(t)
+	ctx := context.Background()
+
+	if _, err := pool.Exec(ctx, `INSERT INTO users (id, name, login, password) VALUES (1, 'alice', 'alice', '')`); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `INSERT INTO products (id, name, price) VALUES (1, 'tea', 100)`); err != nil {
+		t.Fatalf("insert product: %v", err)
+	}
+
+	repo := NewSellRepo(pool)
+	sll := &models.Sell{UserId: 1, ProductId: 1}
+	if err := repo.Create(ctx, sll); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if sll.Price != 100 {
+		t.Errorf("Create() price = %d, want 100", sll.Price)
+	}
+	if _, err := pool.Exec(ctx, `UPDATE products SET price = 500`); err != nil {
+		t.Fatalf("update product: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Price != 100 {
+		t.Errorf("GetByID() price = %d after the product price changed, want 100", got.Price)
+	}
+
+	start := sll.UpdatedAt.Time.Truncate(time.Hour)
+	series, err := repo.TimeSeries(ctx, &models.SalesTimeSeriesFilter{
+		From:     start,
+		To:       start.Add(time.Hour),
+		Bucket:   sell.BucketHour,
+		Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatalf("TimeSeries() error = %v", err)
+	}
+	if len(series) != 1 || series[0].Count != 1 || series[0].Revenue != 100 {
+		t.Errorf("TimeSeries() = %+v, want one sale with revenue 100", series)
+	}
+}
@@ -0,0 +1,178 @@
+package sell
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+)
+
+func berlin(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("LoadLocation() error = %v", err)
+	}
+	return loc
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestBucketStart(t *testing.T) {
+	loc := berlin(t)
+
+	tests := []struct {
+		name   string
+		t      time.Time
+		bucket string
+		want   time.Time
+	}{
+		{name: "hour", t: time.Date(2024, 5, 10, 14, 35, 12, 500, loc), bucket: BucketHour, want: time.Date(2024, 5, 10, 14, 0, 0, 0, loc)},
+		// 02:00-03:00 is repeated on 2024-10-27, first in CEST (00:00Z) then in CET (01:00Z)
+		{name: "hour repeated first", t: utc("2024-10-27T00:30:00Z").In(loc), bucket: BucketHour, want: utc("2024-10-27T00:00:00Z")},
+		{name: "hour repeated second", t: utc("2024-10-27T01:30:00Z").In(loc), bucket: BucketHour, want: utc("2024-10-27T01:00:00Z")},
+		{name: "day", t: time.Date(2024, 5, 10, 14, 35, 0, 0, loc), bucket: BucketDay, want: time.Date(2024, 5, 10, 0, 0, 0, 0, loc)},
+		{name: "unknown bucket is a day", t: time.Date(2024, 5, 10, 14, 35, 0, 0, loc), bucket: "fortnight", want: time.Date(2024, 5, 10, 0, 0, 0, 0, loc)},
+		{name: "day in location of t", t: utc("2024-05-10T23:30:00Z").In(loc), bucket: BucketDay, want: time.Date(2024, 5, 11, 0, 0, 0, 0, loc)},
+		{name: "week from wednesday", t: time.Date(2024, 10, 30, 9, 0, 0, 0, loc), bucket: BucketWeek, want: time.Date(2024, 10, 28, 0, 0, 0, 0, loc)},
+		{name: "week from sunday", t: time.Date(2024, 11, 3, 23, 59, 0, 0, loc), bucket: BucketWeek, want: time.Date(2024, 10, 28, 0, 0, 0, 0, loc)},
+		{name: "week from monday", t: time.Date(2024, 10, 28, 0, 0, 0, 0, loc), bucket: BucketWeek, want: time.Date(2024, 10, 28, 0, 0, 0, 0, loc)},
+		{name: "week across months", t: time.Date(2024, 10, 2, 12, 0, 0, 0, loc), bucket: BucketWeek, want: time.Date(2024, 9, 30, 0, 0, 0, 0, loc)},
+		{name: "month", t: time.Date(2024, 10, 31, 15, 0, 0, 0, loc), bucket: BucketMonth, want: time.Date(2024, 10, 1, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BucketStart(tt.t, tt.bucket); !got.Equal(tt.want) {
+				t.Errorf("BucketStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextBucket(t *testing.T) {
+	loc := berlin(t)
+
+	tests := []struct {
+		name   string
+		t      time.Time
+		bucket string
+		want   time.Time
+	}{
+		{name: "hour", t: time.Date(2024, 5, 10, 14, 0, 0, 0, loc), bucket: BucketHour, want: time.Date(2024, 5, 10, 15, 0, 0, 0, loc)},
+		{name: "hour into repeated hour", t: utc("2024-10-27T00:00:00Z").In(loc), bucket: BucketHour, want: utc("2024-10-27T01:00:00Z")},
+		{name: "short day", t: time.Date(2024, 3, 31, 0, 0, 0, 0, loc), bucket: BucketDay, want: time.Date(2024, 4, 1, 0, 0, 0, 0, loc)},
+		{name: "long day", t: time.Date(2024, 10, 27, 0, 0, 0, 0, loc), bucket: BucketDay, want: time.Date(2024, 10, 28, 0, 0, 0, 0, loc)},
+		{name: "week", t: time.Date(2024, 10, 21, 0, 0, 0, 0, loc), bucket: BucketWeek, want: time.Date(2024, 10, 28, 0, 0, 0, 0, loc)},
+		{name: "month", t: time.Date(2024, 1, 1, 0, 0, 0, 0, loc), bucket: BucketMonth, want: time.Date(2024, 2, 1, 0, 0, 0, 0, loc)},
+		{name: "month across years", t: time.Date(2024, 12, 1, 0, 0, 0, 0, loc), bucket: BucketMonth, want: time.Date(2025, 1, 1, 0, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextBucket(tt.t, tt.bucket); !got.Equal(tt.want) {
+				t.Errorf("NextBucket() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillSeries(t *testing.T) {
+	loc := berlin(t)
+
+	tests := []struct {
+		name    string
+		filter  models.SalesTimeSeriesFilter
+		byStart map[int64]models.SalesBucket
+		want    []models.SalesBucket
+	}{
+		{
+			name: "repeated hour stays two buckets",
+			filter: models.SalesTimeSeriesFilter{
+				From:     utc("2024-10-26T22:00:00Z"),
+				To:       utc("2024-10-27T04:00:00Z"),
+				Bucket:   BucketHour,
+				Location: loc,
+			},
+			byStart: map[int64]models.SalesBucket{
+				utc("2024-10-27T00:00:00Z").Unix(): {Count: 1, Revenue: 100},
+				utc("2024-10-27T01:00:00Z").Unix(): {Count: 2, Revenue: 300},
+			},
+			want: []models.SalesBucket{
+				{Start: utc("2024-10-26T22:00:00Z")},
+				{Start: utc("2024-10-26T23:00:00Z")},
+				{Start: utc("2024-10-27T00:00:00Z"), Count: 1, Revenue: 100},
+				{Start: utc("2024-10-27T01:00:00Z"), Count: 2, Revenue: 300},
+				{Start: utc("2024-10-27T02:00:00Z")},
+				{Start: utc("2024-10-27T03:00:00Z")},
+			},
+		},
+		{
+			name: "skipped hour",
+			filter: models.SalesTimeSeriesFilter{
+				From:     time.Date(2024, 3, 31, 1, 0, 0, 0, loc),
+				To:       time.Date(2024, 3, 31, 4, 0, 0, 0, loc),
+				Bucket:   BucketHour,
+				Location: loc,
+			},
+			want: []models.SalesBucket{
+				{Start: time.Date(2024, 3, 31, 1, 0, 0, 0, loc)},
+				{Start: time.Date(2024, 3, 31, 3, 0, 0, 0, loc)},
+			},
+		},
+		{
+			name: "days from the middle of a day",
+			filter: models.SalesTimeSeriesFilter{
+				From:     time.Date(2024, 3, 30, 12, 0, 0, 0, loc),
+				To:       time.Date(2024, 4, 2, 0, 0, 0, 0, loc),
+				Bucket:   BucketDay,
+				Location: loc,
+			},
+			byStart: map[int64]models.SalesBucket{
+				time.Date(2024, 3, 31, 0, 0, 0, 0, loc).Unix(): {Count: 4, Revenue: 40},
+			},
+			want: []models.SalesBucket{
+				{Start: time.Date(2024, 3, 30, 0, 0, 0, 0, loc)},
+				{Start: time.Date(2024, 3, 31, 0, 0, 0, 0, loc), Count: 4, Revenue: 40},
+				{Start: time.Date(2024, 4, 1, 0, 0, 0, 0, loc)},
+			},
+		},
+		{
+			name: "empty period",
+			filter: models.SalesTimeSeriesFilter{
+				From:     time.Date(2024, 3, 30, 0, 0, 0, 0, loc),
+				To:       time.Date(2024, 3, 30, 0, 0, 0, 0, loc),
+				Bucket:   BucketDay,
+				Location: loc,
+			},
+			want: []models.SalesBucket{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FillSeries(&tt.filter, tt.byStart)
+			if got == nil {
+				t.Fatal("FillSeries() = nil, want an empty slice so it is encoded as []")
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("FillSeries() = %d buckets %v, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				if !got[i].Start.Equal(tt.want[i].Start) || got[i].Count != tt.want[i].Count || got[i].Revenue != tt.want[i].Revenue {
+					t.Errorf("bucket %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+				if got[i].Start.Location() != loc {
+					t.Errorf("bucket %d location = %v, want %v", i, got[i].Start.Location(), loc)
+				}
+			}
+		})
+	}
+}
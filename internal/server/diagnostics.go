@@ -0,0 +1,124 @@
+package server
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/pkg/buildinfo"
+)
+
+type gcStats struct {
+	NumGC       uint32        `json:"num_gc"`
+	LastGC      time.Time     `json:"last_gc"`
+	PauseTotal  time.Duration `json:"pause_total_ns"`
+	LastPause   time.Duration `json:"last_pause_ns"`
+	CPUFraction float64       `json:"cpu_fraction"`
+	NextGCBytes uint64        `json:"next_gc_bytes"`
+	HeapAlloc   uint64        `json:"heap_alloc_bytes"`
+	HeapSys     uint64        `json:"heap_sys_bytes"`
+	HeapObjects uint64        `json:"heap_objects"`
+	TotalAlloc  uint64        `json:"total_alloc_bytes"`
+	SysBytes    uint64        `json:"sys_bytes"`
+	StackInuse  uint64        `json:"stack_inuse_bytes"`
+	LiveObjects uint64        `json:"live_objects"`
+	GCSysBytes  uint64        `json:"gc_sys_bytes"`
+	NumForcedGC uint32        `json:"forced_gc"`
+}
+
+type poolStats struct {
+	AcquireCount         int64         `json:"acquire_count"`
+	AcquireDuration      time.Duration `json:"acquire_duration_ns"`
+	AcquiredConns        int32         `json:"acquired_conns"`
+	CanceledAcquireCount int64         `json:"canceled_acquire_count"`
+	ConstructingConns    int32         `json:"constructing_conns"`
+	EmptyAcquireCount    int64         `json:"empty_acquire_count"`
+	IdleConns            int32         `json:"idle_conns"`
+	MaxConns             int32         `json:"max_conns"`
+	TotalConns           int32         `json:"total_conns"`
+}
+
+type runtimeStats struct {
+	Goroutines int       `json:"goroutines"`
+	NumCPU     int       `json:"num_cpu"`
+	GOMAXPROCS int       `json:"gomaxprocs"`
+	Uptime     string    `json:"uptime"`
+	GC         gcStats   `json:"gc"`
+	Postgres   poolStats `json:"postgres"`
+}
+
+// newDiagnosticsServer pprof, expvar, build info and runtime stats for the internal Http.PprofPort,
+// it must never be exposed through the public API
+func (s *server) newDiagnosticsServer() *echo.Echo {
+	startedAt := time.Now()
+	info := buildinfo.Get(s.cfg.AppVersion)
+	expvar.Publish("build", expvar.Func(func() interface{} { return info }))
+
+	diagnosticsServer := echo.New()
+	diagnosticsServer.HideBanner = true
+	diagnosticsServer.HidePort = true
+
+	diagnosticsServer.GET("/debug/pprof/*", echo.WrapHandler(http.HandlerFunc(pprof.Index)))
+	diagnosticsServer.GET("/debug/pprof/cmdline", echo.WrapHandler(http.HandlerFunc(pprof.Cmdline)))
+	diagnosticsServer.GET("/debug/pprof/profile", echo.WrapHandler(http.HandlerFunc(pprof.Profile)))
+	diagnosticsServer.Match([]string{http.MethodGet, http.MethodPost}, "/debug/pprof/symbol", echo.WrapHandler(http.HandlerFunc(pprof.Symbol)))
+	diagnosticsServer.GET("/debug/pprof/trace", echo.WrapHandler(http.HandlerFunc(pprof.Trace)))
+	diagnosticsServer.GET("/debug/vars", echo.WrapHandler(expvar.Handler()))
+
+	diagnosticsServer.GET("/debug/build", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, info)
+	})
+	diagnosticsServer.GET("/debug/runtime", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, s.runtimeStats(startedAt))
+	})
+	return diagnosticsServer
+}
+
+func (s *server) runtimeStats(startedAt time.Time) runtimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := runtimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Uptime:     time.Since(startedAt).Round(time.Second).String(),
+		GC: gcStats{
+			NumGC:       mem.NumGC,
+			PauseTotal:  time.Duration(mem.PauseTotalNs),
+			LastPause:   time.Duration(mem.PauseNs[(mem.NumGC+255)%256]),
+			CPUFraction: mem.GCCPUFraction,
+			NextGCBytes: mem.NextGC,
+			HeapAlloc:   mem.HeapAlloc,
+			HeapSys:     mem.HeapSys,
+			HeapObjects: mem.HeapObjects,
+			TotalAlloc:  mem.TotalAlloc,
+			SysBytes:    mem.Sys,
+			StackInuse:  mem.StackInuse,
+			LiveObjects: mem.Mallocs - mem.Frees,
+			GCSysBytes:  mem.GCSys,
+			NumForcedGC: mem.NumForcedGC,
+		},
+	}
+	if mem.LastGC > 0 {
+		stats.GC.LastGC = time.Unix(0, int64(mem.LastGC)).UTC()
+	}
+
+	pool := s.dbclient.Stat()
+	stats.Postgres = poolStats{
+		AcquireCount:         pool.AcquireCount(),
+		AcquireDuration:      pool.AcquireDuration(),
+		AcquiredConns:        pool.AcquiredConns(),
+		CanceledAcquireCount: pool.CanceledAcquireCount(),
+		ConstructingConns:    pool.ConstructingConns(),
+		EmptyAcquireCount:    pool.EmptyAcquireCount(),
+		IdleConns:            pool.IdleConns(),
+		MaxConns:             pool.MaxConns(),
+		TotalConns:           pool.TotalConns(),
+	}
+	return stats
+}
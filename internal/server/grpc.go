@@ -0,0 +1,47 @@
+package server
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/Lidne/praktika_MAI/internal/middlewares"
+	"github.com/Lidne/praktika_MAI/internal/product"
+	productGrpc "github.com/Lidne/praktika_MAI/internal/product/delivery/grpc"
+	productsService "github.com/Lidne/praktika_MAI/proto/product"
+)
+
+// newGrpcServer server with registered services and its listener, the caller runs Serve.
+// Connections are encrypted when tlsConfig is set
+func (s *server) newGrpcServer(productRepo product.ProductRepository, mw middlewares.MiddlewareManager, tlsConfig *tls.Config) (*grpc.Server, net.Listener, error) {
+	l, err := net.Listen("tcp", s.cfg.Server.Port)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "net.Listen")
+	}
+
+	opts := []grpc.ServerOption{grpc.KeepaliveParams(keepalive.ServerParameters{
+		MaxConnectionIdle: s.cfg.Server.MaxConnectionIdle * time.Minute,
+		Timeout:           s.cfg.Server.Timeout * time.Second,
+		MaxConnectionAge:  s.cfg.Server.MaxConnectionAge * time.Minute,
+		Time:              s.cfg.Server.Timeout * time.Minute,
+	}), grpc.ChainUnaryInterceptor(mw.GrpcTracing, mw.GrpcAuth(productGrpc.MethodPermissions))}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(opts...)
+
+	productService := productGrpc.NewProductService(s.log, productRepo)
+	productsService.RegisterProductsServiceServer(grpcServer, productService)
+
+	if s.cfg.Server.Development {
+		reflection.Register(grpcServer)
+	}
+
+	return grpcServer, l, nil
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/pkg/health"
+	"github.com/Lidne/praktika_MAI/pkg/kafka"
+)
+
+// newHealthChecker readiness checks of postgres, redis and kafka
+func (s *server) newHealthChecker() *health.Checker {
+	checker := health.NewChecker(s.cfg.Health.Timeout*time.Second, s.cfg.Health.CacheTTL*time.Second)
+	checker.Add("postgres", s.dbclient.Ping)
+	checker.Add("redis", func(ctx context.Context) error {
+		return s.redis.Ping(ctx).Err()
+	})
+	checker.Add("kafka", func(ctx context.Context) error {
+		return kafka.Ping(ctx, s.cfg.Kafka.Brokers)
+	})
+	return checker
+}
+
+// liveness the process is running and serving HTTP, dependencies are not checked
+func liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, echo.Map{"status": health.StatusUp})
+}
+
+// readiness 503 while any dependency is down so that traffic is routed to other instances
+func readiness(checker *health.Checker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		report := checker.Check(c.Request().Context())
+		status := http.StatusOK
+		if report.Status != health.StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+		return c.JSON(status, report)
+	}
+}
@@ -1,45 +1,47 @@
 package server
 
 import (
-	"net/http"
+	"crypto/tls"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	echoSwagger "github.com/swaggo/echo-swagger"
 
-	"github.com/Lidne/praktika_MAI/docs"
+	statisticsApi "github.com/Lidne/praktika_MAI/internal/api"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	authHttp "github.com/Lidne/praktika_MAI/internal/auth/delivery/http"
+	"github.com/Lidne/praktika_MAI/internal/middlewares"
+	productHttp "github.com/Lidne/praktika_MAI/internal/product/delivery/http"
+	sellHttp "github.com/Lidne/praktika_MAI/internal/sell/delivery/http"
+	sessionHttp "github.com/Lidne/praktika_MAI/internal/session/delivery/http"
+	statisticsRepo "github.com/Lidne/praktika_MAI/internal/statistics/repository"
+	userHttp "github.com/Lidne/praktika_MAI/internal/user/delivery/http"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
 )
 
-func (s *server) runHttpServer() {
-	s.echo.GET("/health", func(c echo.Context) error {
-		return c.String(http.StatusOK, "Ok")
-	})
-	s.echo.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
-	s.mapRoutes()
-
-	go func() {
-		s.echo.Server.ReadTimeout = time.Second * s.cfg.Http.ReadTimeout
-		s.echo.Server.WriteTimeout = time.Second * s.cfg.Http.WriteTimeout
-		s.echo.Server.MaxHeaderBytes = maxHeaderBytes
-		if err := s.echo.Start(s.cfg.Http.Port); err != nil {
-			s.log.Fatalf("Error starting Server: ", err)
-		}
-	}()
+// runHttpServer blocks until the server is stopped by Shutdown, serves HTTPS when tlsConfig is set
+func (s *server) runHttpServer(tlsConfig *tls.Config) error {
+	srv := s.echo.Server
+	if tlsConfig != nil {
+		srv = s.echo.TLSServer
+		srv.TLSConfig = tlsConfig
+	}
+	srv.Addr = s.cfg.Http.Port
+	srv.ReadTimeout = time.Second * s.cfg.Http.ReadTimeout
+	srv.WriteTimeout = time.Second * s.cfg.Http.WriteTimeout
+	srv.MaxHeaderBytes = maxHeaderBytes
+	return ignoreServerClosed(s.echo.StartServer(srv))
 }
 
-func (s *server) mapRoutes() {
-	docs.SwaggerInfo.Version = "1.0"
-	docs.SwaggerInfo.Title = "Products microservice"
-	docs.SwaggerInfo.Description = "Products REST API microservice."
-	docs.SwaggerInfo.Version = "1.0"
-	docs.SwaggerInfo.BasePath = "/api/v1"
-
-	s.echo.GET("/swagger/*", echoSwagger.WrapHandler)
-	s.echo.Use(middleware.Logger())
-	s.echo.Pre(middleware.HTTPSRedirect())
+func (s *server) mapRoutes(services *Services, mw middlewares.MiddlewareManager) {
+	s.echo.HideBanner = true
+	s.echo.HTTPErrorHandler = httpErrors.NewHTTPErrorHandler(s.log)
+	s.echo.Use(middleware.RequestID())
+	s.echo.Use(mw.Metrics)
+	s.echo.Use(mw.Tracing)
+	s.echo.Use(mw.RequestLogger)
 	s.echo.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins: []string{"*"},
 		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderXRequestID, csrfTokenHeader},
@@ -49,7 +51,6 @@ func (s *server) mapRoutes() {
 		DisablePrintStack: true,
 		DisableStackAll:   true,
 	}))
-	s.echo.Use(middleware.RequestID())
 	s.echo.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 		Level: gzipLevel,
 		Skipper: func(c echo.Context) bool {
@@ -58,4 +59,20 @@ func (s *server) mapRoutes() {
 	}))
 	s.echo.Use(middleware.Secure())
 	s.echo.Use(middleware.BodyLimit(bodyLimit))
+
+	s.echo.GET("/health", liveness) // kept for existing probes, same as /health/live
+	s.echo.GET("/health/live", liveness)
+	s.echo.GET("/health/ready", readiness(s.newHealthChecker()))
+	s.echo.GET("/swagger/*", echoSwagger.WrapHandler)
+
+	public := s.echo.Group("/api")
+	api := s.echo.Group("/api", mw.AuthMiddleware, mw.CSRFMiddleware)
+	authHttp.NewRouter(public, api, services.user, services.session, services.validate, s.cfg)
+	sessionHttp.NewRouter(api, services.session, s.cfg)
+	api.GET("/admin/log-level", services.getLogLevel, mw.RequirePermission(auth.PermSystemWrite))
+	api.PUT("/admin/log-level", services.setLogLevel, mw.RequirePermission(auth.PermSystemWrite))
+	userHttp.NewRouter(api, services.user, services.session, services.validate, mw)
+	productHttp.NewRouter(api, services.product, services.validate, mw)
+	sellHttp.NewRouter(api, services.sell, services.validate, mw)
+	statisticsApi.NewRouter(api, statisticsRepo.NewStatisticsRepo(s.dbclient), mw.RequirePermission(auth.PermSalesRead))
 }
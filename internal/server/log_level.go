@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+type logLevelRequest struct {
+	Level string `json:"level" validate:"required,oneof=debug info warn error dpanic panic fatal"`
+}
+
+type logLevelResponse struct {
+	Level string `json:"level"`
+}
+
+// getLogLevel godoc
+//
+//	@Summary		Get Log Level
+//	@Tags			Admin
+//	@Description	Current level of the service logger
+//	@ID				get-log-level
+//	@Produce		json
+//	@Success		200	{object}	logLevelResponse
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/admin/log-level [get]
+func (s *Services) getLogLevel(c echo.Context) error {
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": logLevelResponse{Level: s.log.Level()},
+	})
+}
+
+// setLogLevel godoc
+//
+//	@Summary		Set Log Level
+//	@Tags			Admin
+//	@Description	Change the level of the service logger at runtime, the change is lost on restart
+//	@ID				set-log-level
+//	@Accept			json
+//	@Produce		json
+//	@Param			level	body	logLevelRequest	true	"New level"
+//	@Success		200	{object}	logLevelResponse
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/admin/log-level [put]
+func (s *Services) setLogLevel(c echo.Context) error {
+	var req logLevelRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if err := s.validate.StructCtx(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	previous := s.log.Level()
+	if err := s.log.SetLevel(req.Level); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	logger.FromContext(c.Request().Context(), s.log).Warnf("log level changed from %s to %s", previous, req.Level)
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": logLevelResponse{Level: req.Level},
+	})
+}
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/Lidne/praktika_MAI/pkg/postgres"
+)
+
+// newMetricsServer server of prometheus metrics for the separate metrics port
+func (s *server) newMetricsServer() *echo.Echo {
+	prometheus.MustRegister(postgres.NewPoolCollector(s.dbclient))
+
+	metricsServer := echo.New()
+	metricsServer.HideBanner = true
+	metricsServer.HidePort = true
+	metricsServer.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	return metricsServer
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"crypto/tls"
+
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/pkg/lifecycle"
+	"github.com/Lidne/praktika_MAI/pkg/tlsconfig"
+)
+
+// newTLSConfig TLS config of a server reloading its certificates on SIGHUP, nil when TLS is disabled
+func (s *server) newTLSConfig(lc *lifecycle.Manager, name string, cfg config.TLS, nextProtos ...string) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig, reloader, err := tlsconfig.NewServerConfig(tlsconfig.Options{
+		CertFile:     cfg.CertFile,
+		KeyFile:      cfg.KeyFile,
+		ClientCAFile: cfg.ClientCAFile,
+		MinVersion:   cfg.MinVersion,
+		CipherSuites: cfg.CipherSuites,
+		NextProtos:   nextProtos,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, name)
+	}
+	lc.OnReload(name+" certificates", reloader.Reload)
+
+	s.log.Infof("TLS enabled for %s, mutual TLS: %t", name, cfg.ClientCAFile != "")
+	return tlsConfig, nil
+}
@@ -0,0 +1,94 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/session"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	httpUtils "github.com/Lidne/praktika_MAI/pkg/http_utils"
+)
+
+type handlers struct {
+	sessions session.SessionRepository
+	cfg      *config.Config
+}
+
+// getSessions godoc
+//
+//	@Summary		Get Sessions
+//	@Tags			Auth
+//	@Description	Active sessions of the authenticated user, admins may list sessions of any user
+//	@ID				get-sessions
+//	@Produce		json
+//	@Param			user_id	query	int	false	"User ID, defaults to the authenticated user"
+//	@Success		200	{array}		models.SessionResponse
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/sessions [get]
+func (h *handlers) getSessions(c echo.Context) error {
+	userId, err := httpUtils.QueryInt(c, "user_id")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if userId == nil {
+		id := auth.UserID(c)
+		userId = &id
+	}
+	if !auth.CanAccess(c, auth.PermUsersWrite, *userId) {
+		return httpUtils.ForbiddenResponse(c)
+	}
+
+	sessions, err := h.sessions.ListByUser(c.Request().Context(), *userId)
+	if err != nil {
+		return err
+	}
+	current, _ := auth.GetSession(c)
+	res := []models.SessionResponse{}
+	for _, sess := range sessions {
+		res = append(res, models.SessionResponse{Session: sess, Current: current != nil && current.ID == sess.ID})
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": res,
+	})
+}
+
+// deleteSession godoc
+//
+//	@Summary		Revoke Session
+//	@Tags			Auth
+//	@Description	End a session of the authenticated user, admins may end sessions of any user
+//	@ID				delete-session
+//	@Param			id	path	string	true	"Session ID"
+//	@Success		204
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Failure		404	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/sessions/{id} [delete]
+func (h *handlers) deleteSession(c echo.Context) error {
+	sess, err := h.sessions.GetByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, session.ErrSessionNotFound) {
+			return c.JSON(http.StatusNotFound, httpErrors.NewNotFoundError(err.Error()))
+		}
+		return err
+	}
+	if !auth.CanAccess(c, auth.PermUsersWrite, sess.UserID) {
+		return httpUtils.ForbiddenResponse(c)
+	}
+	if err := h.sessions.Delete(c.Request().Context(), sess); err != nil {
+		return err
+	}
+	if current, ok := auth.GetSession(c); ok && current.ID == sess.ID {
+		c.SetCookie(auth.ExpiredSessionCookie(h.cfg))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
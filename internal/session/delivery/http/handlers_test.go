@@ -0,0 +1,218 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/session"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Errorf(template string, args ...interface{}) {}
+
+// fakeSessions in-memory session repository
+type fakeSessions struct {
+	sessions map[string]models.Session
+}
+
+func (r *fakeSessions) Create(ctx context.Context, session *models.Session, ttl time.Duration) error {
+	r.sessions[session.ID] = *session
+	return nil
+}
+
+func (r *fakeSessions) Touch(ctx context.Context, id string, ttl time.Duration) (*models.Session, error) {
+	return r.GetByID(ctx, id)
+}
+
+func (r *fakeSessions) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	sess, ok := r.sessions[id]
+	if !ok {
+		return nil, session.ErrSessionNotFound
+	}
+	return &sess, nil
+}
+
+func (r *fakeSessions) ListByUser(ctx context.Context, userID int) ([]models.Session, error) {
+	res := []models.Session{}
+	for _, sess := range r.sessions {
+		if sess.UserID == userID {
+			res = append(res, sess)
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeSessions) Delete(ctx context.Context, session *models.Session) error {
+	delete(r.sessions, session.ID)
+	return nil
+}
+
+func (r *fakeSessions) DeleteByUser(ctx context.Context, userID int) error {
+	for id, sess := range r.sessions {
+		if sess.UserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+var (
+	adminSession = models.Session{ID: "admin-session", UserID: 1, Login: "admin", Role: string(auth.RoleAdmin)}
+	bobSession   = models.Session{ID: "bob-session", UserID: 2, Login: "bob", Role: string(auth.RoleUser)}
+	bobPhone     = models.Session{ID: "bob-phone", UserID: 2, Login: "bob", Role: string(auth.RoleUser)}
+	carolSession = models.Session{ID: "carol-session", UserID: 3, Login: "carol", Role: string(auth.RoleUser)}
+)
+
+func TestDeleteSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     models.Session
+		target      string
+		wantStatus  int
+		wantDeleted bool
+		wantCookie  bool
+	}{
+		{
+			name:        "own other session",
+			current:     bobSession,
+			target:      bobPhone.ID,
+			wantStatus:  http.StatusNoContent,
+			wantDeleted: true,
+		},
+		{
+			name:        "own current session",
+			current:     bobSession,
+			target:      bobSession.ID,
+			wantStatus:  http.StatusNoContent,
+			wantDeleted: true,
+			wantCookie:  true,
+		},
+		{
+			name:        "admin revokes a user session",
+			current:     adminSession,
+			target:      bobPhone.ID,
+			wantStatus:  http.StatusNoContent,
+			wantDeleted: true,
+		},
+		{
+			name:       "another user session",
+			current:    carolSession,
+			target:     bobPhone.ID,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "missing session",
+			current:    bobSession,
+			target:     "missing",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := newSessions()
+			e := newServer(sessions, tt.current)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/sessions/"+tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if _, ok := sessions.sessions[tt.target]; ok == tt.wantDeleted && tt.target != "missing" {
+				t.Errorf("session %s kept = %v, want deleted = %v", tt.target, ok, tt.wantDeleted)
+			}
+			cookies := rec.Result().Cookies()
+			if expired := len(cookies) == 1 && cookies[0].MaxAge < 0; expired != tt.wantCookie {
+				t.Errorf("cookies = %+v, want the cookie expired = %v", cookies, tt.wantCookie)
+			}
+		})
+	}
+}
+
+func TestGetSessions(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    models.Session
+		target     string
+		wantStatus int
+		wantBody   []string
+	}{
+		{
+			name:       "own sessions",
+			current:    bobSession,
+			target:     "/api/sessions",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"id":"bob-session","user_id":2`, `"current":true`, `"id":"bob-phone"`},
+		},
+		{
+			name:       "another user sessions",
+			current:    carolSession,
+			target:     "/api/sessions?user_id=2",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "admin lists user sessions",
+			current:    adminSession,
+			target:     "/api/sessions?user_id=2",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"id":"bob-session"`, `"id":"bob-phone"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newServer(newSessions(), tt.current)
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			for _, want := range tt.wantBody {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body %s does not contain %s", rec.Body, want)
+				}
+			}
+		})
+	}
+}
+
+func newSessions() *fakeSessions {
+	sessions := &fakeSessions{sessions: map[string]models.Session{}}
+	for _, sess := range []models.Session{adminSession, bobSession, bobPhone, carolSession} {
+		sessions.sessions[sess.ID] = sess
+	}
+	return sessions
+}
+
+// newServer echo server with session routes and the requests authenticated by the current session
+func newServer(sessions session.SessionRepository, current models.Session) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = httpErrors.NewHTTPErrorHandler(nopLogger{})
+	api := e.Group("/api", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			sess := current
+			auth.SetSession(c, &sess)
+			auth.SetClaims(c, auth.SessionClaims(&sess))
+			return next(c)
+		}
+	})
+	NewRouter(api, sessions, &config.Config{Http: config.Http{SessionCookieName: "session"}})
+	return e
+}
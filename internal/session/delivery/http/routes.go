@@ -0,0 +1,16 @@
+package http
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/session"
+)
+
+// NewRouter register session routes
+func NewRouter(e *echo.Group, sessions session.SessionRepository, cfg *config.Config) {
+	h := &handlers{sessions: sessions, cfg: cfg}
+	sessionsGroup := e.Group("/sessions")
+	sessionsGroup.GET("", h.getSessions)
+	sessionsGroup.DELETE("/:id", h.deleteSession)
+}
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+)
+
+const tokenBytes = 32
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionRepository Session
+type SessionRepository interface {
+	// Create store the session for ttl
+	Create(ctx context.Context, session *models.Session, ttl time.Duration) error
+	// Touch return the session and extend its lifetime to ttl from now
+	Touch(ctx context.Context, id string, ttl time.Duration) (*models.Session, error)
+	GetByID(ctx context.Context, id string) (*models.Session, error)
+	ListByUser(ctx context.Context, userID int) ([]models.Session, error)
+	Delete(ctx context.Context, session *models.Session) error
+	// DeleteByUser end every session of the user
+	DeleteByUser(ctx context.Context, userID int) error
+}
+
+// NewToken random cookie token and the session ID derived from it
+func NewToken() (token string, id string, err error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", errors.Wrap(err, "rand.Read")
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, TokenID(token), nil
+}
+
+// TokenID session ID of the cookie token, only the hash is stored so a leaked
+// session list or redis dump can not be replayed as a cookie
+func TokenID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/session"
+)
+
+const (
+	sessionPrefix      = "session:"
+	userSessionsPrefix = "user_sessions:"
+)
+
+// touchScript refresh the session only while it still exists and is listed for its user,
+// so that a session deleted after it was read by Touch is never recreated.
+// KEYS: session, user sessions; ARGV: session json, ttl in ms, session id
+var touchScript = redis.NewScript(`
+if redis.call("SISMEMBER", KEYS[2], ARGV[3]) == 0 then
+	return 0
+end
+if not redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2], "XX") then
+	return 0
+end
+redis.call("PEXPIRE", KEYS[2], ARGV[2])
+return 1
+`)
+
+// sessionRepo
+type sessionRepo struct {
+	client *redis.Client
+}
+
+// NewSessionRepo sessionRepo constructor
+func NewSessionRepo(client *redis.Client) session.SessionRepository {
+	return &sessionRepo{client: client}
+}
+
+func (r *sessionRepo) Create(ctx context.Context, sess *models.Session, ttl time.Duration) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sessionRepo.Create")
+	defer span.Finish()
+
+	b, err := json.Marshal(sess)
+	if err != nil {
+		return errors.Wrap(err, "sessionRepo.Create.Marshal")
+	}
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, sessionKey(sess.ID), b, ttl)
+		pipe.SAdd(ctx, userSessionsKey(sess.UserID), sess.ID)
+		pipe.Expire(ctx, userSessionsKey(sess.UserID), ttl)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "sessionRepo.Create.TxPipelined")
+	}
+	return nil
+}
+
+func (r *sessionRepo) Touch(ctx context.Context, id string, ttl time.Duration) (*models.Session, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sessionRepo.Touch")
+	defer span.Finish()
+
+	sess, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sess.LastSeenAt = time.Now().UTC()
+	b, err := json.Marshal(sess)
+	if err != nil {
+		return nil, errors.Wrap(err, "sessionRepo.Touch.Marshal")
+	}
+	touched, err := touchScript.Run(
+		ctx,
+		r.client,
+		[]string{sessionKey(sess.ID), userSessionsKey(sess.UserID)},
+		b, ttl.Milliseconds(), sess.ID,
+	).Int()
+	if err != nil {
+		return nil, errors.Wrap(err, "sessionRepo.Touch.touchScript")
+	}
+	if touched == 0 {
+		return nil, session.ErrSessionNotFound
+	}
+	return sess, nil
+}
+
+func (r *sessionRepo) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sessionRepo.GetByID")
+	defer span.Finish()
+
+	b, err := r.client.Get(ctx, sessionKey(id)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, session.ErrSessionNotFound
+		}
+		return nil, errors.Wrap(err, "sessionRepo.GetByID.Get")
+	}
+	sess := &models.Session{}
+	if err := json.Unmarshal(b, sess); err != nil {
+		return nil, errors.Wrap(err, "sessionRepo.GetByID.Unmarshal")
+	}
+	return sess, nil
+}
+
+func (r *sessionRepo) ListByUser(ctx context.Context, userID int) ([]models.Session, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sessionRepo.ListByUser")
+	defer span.Finish()
+
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "sessionRepo.ListByUser.SMembers")
+	}
+	sessions := []models.Session{}
+	if len(ids) == 0 {
+		return sessions, nil
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "sessionRepo.ListByUser.MGet")
+	}
+
+	expired := []interface{}{}
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			expired = append(expired, ids[i])
+			continue
+		}
+		var sess models.Session
+		if err := json.Unmarshal([]byte(s), &sess); err != nil {
+			return nil, errors.Wrap(err, "sessionRepo.ListByUser.Unmarshal")
+		}
+		sessions = append(sessions, sess)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+	if len(expired) > 0 {
+		if err := r.client.SRem(ctx, userSessionsKey(userID), expired...).Err(); err != nil {
+			return nil, errors.Wrap(err, "sessionRepo.ListByUser.SRem")
+		}
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepo) Delete(ctx context.Context, sess *models.Session) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sessionRepo.Delete")
+	defer span.Finish()
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(sess.ID))
+		pipe.SRem(ctx, userSessionsKey(sess.UserID), sess.ID)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "sessionRepo.Delete.TxPipelined")
+	}
+	return nil
+}
+
+func (r *sessionRepo) DeleteByUser(ctx context.Context, userID int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "sessionRepo.DeleteByUser")
+	defer span.Finish()
+
+	ids, err := r.client.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil {
+		return errors.Wrap(err, "sessionRepo.DeleteByUser.SMembers")
+	}
+	keys := []string{userSessionsKey(userID)}
+	for _, id := range ids {
+		keys = append(keys, sessionKey(id))
+	}
+	if err := r.client.Del(ctx, keys...).Err(); err != nil {
+		return errors.Wrap(err, "sessionRepo.DeleteByUser.Del")
+	}
+	return nil
+}
+
+func sessionKey(id string) string {
+	return sessionPrefix + id
+}
+
+func userSessionsKey(userID int) string {
+	return userSessionsPrefix + strconv.Itoa(userID)
+}
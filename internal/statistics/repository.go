@@ -0,0 +1,14 @@
+package statistics
+
+import (
+	"context"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+)
+
+// StatisticsRepository sales aggregates
+type StatisticsRepository interface {
+	Sales(ctx context.Context, filter *models.StatisticsFilter) (*models.SalesStatistics, error)
+	TopBuyers(ctx context.Context, filter *models.StatisticsFilter) ([]models.BuyerStatistics, error)
+	Products(ctx context.Context, filter *models.StatisticsFilter) ([]models.ProductStatistics, error)
+}
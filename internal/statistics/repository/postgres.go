@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/statistics"
+	"github.com/Lidne/praktika_MAI/pkg/postgres"
+)
+
+// statisticsRepo revenue is calculated with the price stored in each bargain
+type statisticsRepo struct {
+	client postgres.Client
+}
+
+// NewStatisticsRepo statisticsRepo constructor
+func NewStatisticsRepo(client postgres.Client) statistics.StatisticsRepository {
+	return &statisticsRepo{client: client}
+}
+
+// period WHERE clause limiting bargains to the filter dates
+func period(filter *models.StatisticsFilter) *postgres.Builder {
+	b := &postgres.Builder{}
+	if filter.From != nil {
+		b.Where("b.updatedat >= " + b.Arg(*filter.From))
+	}
+	if filter.To != nil {
+		b.Where("b.updatedat < " + b.Arg(*filter.To))
+	}
+	return b
+}
+
+func (r *statisticsRepo) Sales(ctx context.Context, filter *models.StatisticsFilter) (_ *models.SalesStatistics, err error) {
+	span, ctx := postgres.StartSpan(ctx, "statisticsRepo.Sales")
+	defer postgres.Finish(span, &err)
+
+	b := period(filter)
+	q := `SELECT b.updatedat::date AS day, count(*), coalesce(sum(b.price), 0)
+		FROM bargains b` + b.WhereClause() + `
+		GROUP BY day ORDER BY day`
+	rows, err := r.client.Query(ctx, q, b.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "statisticsRepo.Sales.Query")
+	}
+	defer rows.Close()
+
+	stats := &models.SalesStatistics{Daily: []models.DailySales{}}
+	for rows.Next() {
+		day := models.DailySales{}
+		if err := rows.Scan(&day.Date, &day.Count, &day.Revenue); err != nil {
+			return nil, errors.Wrap(err, "statisticsRepo.Sales.Scan")
+		}
+		stats.Daily = append(stats.Daily, day)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "statisticsRepo.Sales.rows")
+	}
+
+	q = `SELECT coalesce(avg(items), 0), coalesce(avg(revenue), 0) FROM (
+		SELECT count(*) AS items, sum(b.price) AS revenue
+		FROM bargains b` + b.WhereClause() + `
+		GROUP BY b.user_id, b.updatedat::date
+	) baskets`
+	if err := r.client.QueryRow(ctx, q, b.Args()...).Scan(&stats.AverageBasket.Items, &stats.AverageBasket.Revenue); err != nil {
+		return nil, errors.Wrap(err, "statisticsRepo.Sales.QueryRow")
+	}
+
+	return stats, nil
+}
+
+func (r *statisticsRepo) TopBuyers(ctx context.Context, filter *models.StatisticsFilter) (_ []models.BuyerStatistics, err error) {
+	span, ctx := postgres.StartSpan(ctx, "statisticsRepo.TopBuyers")
+	defer postgres.Finish(span, &err)
+
+	b := period(filter)
+	q := `SELECT u.id, u.name, count(*) AS purchases, coalesce(sum(b.price), 0) AS spent
+		FROM bargains b JOIN users u ON u.id = b.user_id` + b.WhereClause() + `
+		GROUP BY u.id, u.name
+		ORDER BY spent DESC, purchases DESC, u.id
+		LIMIT ` + b.Arg(filter.Limit)
+	rows, err := r.client.Query(ctx, q, b.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "statisticsRepo.TopBuyers.Query")
+	}
+	defer rows.Close()
+
+	buyers := []models.BuyerStatistics{}
+	for rows.Next() {
+		buyer := models.BuyerStatistics{}
+		if err := rows.Scan(&buyer.UserID, &buyer.Name, &buyer.Purchases, &buyer.Spent); err != nil {
+			return nil, errors.Wrap(err, "statisticsRepo.TopBuyers.Scan")
+		}
+		buyers = append(buyers, buyer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "statisticsRepo.TopBuyers.rows")
+	}
+
+	return buyers, nil
+}
+
+func (r *statisticsRepo) Products(ctx context.Context, filter *models.StatisticsFilter) (_ []models.ProductStatistics, err error) {
+	span, ctx := postgres.StartSpan(ctx, "statisticsRepo.Products")
+	defer postgres.Finish(span, &err)
+
+	b := period(filter)
+	q := `SELECT p.id, p.name, count(*) AS units, coalesce(sum(b.price), 0) AS revenue
+		FROM bargains b JOIN products p ON p.id = b.product_id` + b.WhereClause() + `
+		GROUP BY p.id, p.name
+		ORDER BY revenue DESC, units DESC, p.id
+		LIMIT ` + b.Arg(filter.Limit)
+	rows, err := r.client.Query(ctx, q, b.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "statisticsRepo.Products.Query")
+	}
+	defer rows.Close()
+
+	products := []models.ProductStatistics{}
+	for rows.Next() {
+		product := models.ProductStatistics{}
+		if err := rows.Scan(&product.ProductID, &product.Name, &product.Units, &product.Revenue); err != nil {
+			return nil, errors.Wrap(err, "statisticsRepo.Products.Scan")
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "statisticsRepo.Products.rows")
+	}
+
+	return products, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/pkg/postgres/postgrestest"
+)
+
+func TestStatisticsUseSellPrice(t *testing.T) {
+	pool := postgrestest.New(t)
+	ctx := context.Background()
+
+	setup := []string{
+		`INSERT INTO users (id, name, login, password) VALUES (1, 'alice', 'alice', ''), (2, 'bob', 'bob', '')`,
+		`INSERT INTO products (id, name, price) VALUES (1, 'tea', 100), (2, 'cup', 300)`,
+		`INSERT INTO bargains (user_id, product_id, price, updatedat) VALUES
+			(1, 1, 100, '2024-03-01 10:00'),
+			(1, 2, 250, '2024-03-01 11:00'),
+			(2, 1, 80, '2024-03-01 12:00'),
+			(2, 1, 100, '2024-03-02 09:00'),
+			(1, 1, 100, '2024-04-01 09:00')`,
+		// price changes must not rewrite past revenue
+		`UPDATE products SET price = price * 10`,
+	}
+	for _, q := range setup {
+		if _, err := pool.Exec(ctx, q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+
+	from, to := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+	filter := &models.StatisticsFilter{From: &from, To: &to, Limit: 10}
+	repo := NewStatisticsRepo(pool)
+
+	sales, err := repo.Sales(ctx, filter)
+	if err != nil {
+		t.Fatalf("Sales() error = %v", err)
+	}
+	if len(sales.Daily) != 2 {
+		t.Fatalf("Daily = %+v, want 2 days", sales.Daily)
+	}
+	if d := sales.Daily[0]; d.Count != 3 || d.Revenue != 430 {
+		t.Errorf("2024-03-01 = %d sales, %d revenue, want 3, 430", d.Count, d.Revenue)
+	}
+	if d := sales.Daily[1]; d.Count != 1 || d.Revenue != 100 {
+		t.Errorf("2024-03-02 = %d sales, %d revenue, want 1, 100", d.Count, d.Revenue)
+	}
+	// baskets: alice 03-01 {100, 250}, bob 03-01 {80}, bob 03-02 {100}
+	if b := sales.AverageBasket; math.Abs(b.Items-4.0/3) > 1e-9 || math.Abs(b.Revenue-530.0/3) > 1e-9 {
+		t.Errorf("AverageBasket = %+v, want 1.33 items, 176.67 revenue", b)
+	}
+
+	buyers, err := repo.TopBuyers(ctx, filter)
+	if err != nil {
+		t.Fatalf("TopBuyers() error = %v", err)
+	}
+	wantBuyers := []models.BuyerStatistics{
+		{UserID: 1, Name: "alice", Purchases: 2, Spent: 350},
+		{UserID: 2, Name: "bob", Purchases: 2, Spent: 180},
+	}
+	if len(buyers) != len(wantBuyers) {
+		t.Fatalf("TopBuyers() = %+v, want %+v", buyers, wantBuyers)
+	}
+	for i := range wantBuyers {
+		if buyers[i] != wantBuyers[i] {
+			t.Errorf("TopBuyers()[%d] = %+v, want %+v", i, buyers[i], wantBuyers[i])
+		}
+	}
+
+	products, err := repo.Products(ctx, filter)
+	if err != nil {
+		t.Fatalf("Products() error = %v", err)
+	}
+	wantProducts := []models.ProductStatistics{
+		{ProductID: 1, Name: "tea", Units: 3, Revenue: 280},
+		{ProductID: 2, Name: "cup", Units: 1, Revenue: 250},
+	}
+	if len(products) != len(wantProducts) {
+		t.Fatalf("Products() = %+v, want %+v", products, wantProducts)
+	}
+	for i := range wantProducts {
+		if products[i] != wantProducts[i] {
+			t.Errorf("Products()[%d] = %+v, want %+v", i, products[i], wantProducts[i])
+		}
+	}
+}
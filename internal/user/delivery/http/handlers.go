@@ -0,0 +1,239 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/session"
+	"github.com/Lidne/praktika_MAI/internal/user"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	httpUtils "github.com/Lidne/praktika_MAI/pkg/http_utils"
+)
+
+type handlers struct {
+	users    user.UserRepository
+	sessions session.SessionRepository
+	validate *validator.Validate
+}
+
+// getUsers godoc
+//
+//	@Summary		Get Users
+//	@Tags			Users
+//	@Description	Get a page of users
+//	@ID				get-users
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit		query	int		false	"Page size"
+//	@Param			after		query	string	false	"Cursor returned as next_cursor"
+//	@Param			sort		query	string	false	"Sort field: id, name, login, updated_at, prefix with - for descending order"
+//	@Param			is_admin	query	bool	false	"Admin flag"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/users [get]
+func (h *handlers) getUsers(c echo.Context) error {
+	query, err := httpUtils.PaginationQuery(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	filter := &models.UserFilter{}
+	if filter.IsAdmin, err = httpUtils.QueryBool(c, "is_admin"); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+
+	list, err := h.users.FindAll(c.Request().Context(), query, filter)
+	if err != nil {
+		return httpUtils.ListErrorResponse(c, err)
+	}
+	res := []models.UserResponse{}
+	for _, usr := range list.Users {
+		res = append(res, usr.Response())
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data":        res,
+		"next_cursor": list.NextCursor,
+	})
+}
+
+// getUserById godoc
+//
+//	@Summary		Get User By ID
+//	@Tags			Users
+//	@Description	Get a user by ID
+//	@ID				get-user-by-id
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	string	true	"User ID"
+//	@Success		200	{object}	map[string]interface{}	"data"
+//	@Failure		404	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/users/{id} [get]
+func (h *handlers) getUserById(c echo.Context) error {
+	userId := c.Param("id")
+	id, err := strconv.Atoi(userId)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid user id"))
+	}
+	if !auth.CanAccess(c, auth.PermUsersRead, id) {
+		return httpUtils.ForbiddenResponse(c)
+	}
+	usr, err := h.users.GetByID(c.Request().Context(), userId)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": usr.Response(),
+	})
+}
+
+type userRequest struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	Login    string `json:"login" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	IsAdmin  bool   `json:"is_admin"`
+}
+
+type userUpdateRequest struct {
+	Name     string `json:"name" validate:"required,max=255"`
+	Login    string `json:"login" validate:"required,max=255"`
+	Password string `json:"password" validate:"omitempty,min=8,max=72"`
+	IsAdmin  bool   `json:"is_admin"`
+}
+
+// createUser godoc
+//
+//	@Summary		Create User
+//	@Tags			Users
+//	@Description	Create a new user, the password is stored as a bcrypt hash
+//	@ID				create-user
+//	@Accept			json
+//	@Produce		json
+//	@Param			user	body	userRequest	true	"User"
+//	@Success		201	{object}	models.UserResponse
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/users [post]
+func (h *handlers) createUser(c echo.Context) error {
+	var req userRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if err := h.validate.StructCtx(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	usr := &models.User{Name: req.Name, Login: req.Login, Password: req.Password, IsAdmin: req.IsAdmin}
+	if err := usr.HashPassword(); err != nil {
+		return err
+	}
+	if err := h.users.Create(c.Request().Context(), usr); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, echo.Map{
+		"data": usr.Response(),
+	})
+}
+
+// updateUser godoc
+//
+//	@Summary		Update User
+//	@Tags			Users
+//	@Description	Update a user, the password is changed only when given
+//	@ID				update-user
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int					true	"User ID"
+//	@Param			user	body	userUpdateRequest	true	"User"
+//	@Success		200	{object}	models.UserResponse
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		404	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/users/{id} [put]
+func (h *handlers) updateUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid user id"))
+	}
+	if !auth.CanAccess(c, auth.PermUsersWrite, id) {
+		return httpUtils.ForbiddenResponse(c)
+	}
+	var req userUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	if err := h.validate.StructCtx(c.Request().Context(), &req); err != nil {
+		return err
+	}
+
+	usr, err := h.users.GetByID(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	if usr.IsAdmin != req.IsAdmin && !auth.Allowed(c, auth.PermUsersWrite) {
+		return httpUtils.ForbiddenResponse(c)
+	}
+	// sessions keep the role they were started with, a new role or password ends them
+	endSessions := usr.IsAdmin != req.IsAdmin || req.Password != ""
+	usr.Name = req.Name
+	usr.Login = req.Login
+	usr.IsAdmin = req.IsAdmin
+	if req.Password != "" {
+		usr.Password = req.Password
+		if err := usr.HashPassword(); err != nil {
+			return err
+		}
+	}
+	if err := h.users.Update(c.Request().Context(), usr); err != nil {
+		return err
+	}
+	if endSessions {
+		if err := h.sessions.DeleteByUser(c.Request().Context(), usr.ID); err != nil {
+			return err
+		}
+	}
+	return c.JSON(http.StatusOK, echo.Map{
+		"data": usr.Response(),
+	})
+}
+
+// deleteUser godoc
+//
+//	@Summary		Delete User
+//	@Tags			Users
+//	@Description	Delete a user by ID
+//	@ID				delete-user
+//	@Param			id	path	int	true	"User ID"
+//	@Success		204
+//	@Failure		400	{object}	httpErrors.RestError
+//	@Failure		404	{object}	httpErrors.RestError
+//	@Failure		500	{object}	httpErrors.RestError
+//	@Failure		403	{object}	httpErrors.RestError
+//	@Security		BearerAuth
+//	@Router			/api/users/{id} [delete]
+func (h *handlers) deleteUser(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError("invalid user id"))
+	}
+	if err := h.users.Delete(c.Request().Context(), userId); err != nil {
+		return err
+	}
+	if err := h.sessions.DeleteByUser(c.Request().Context(), userId); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusNoContent)
+}
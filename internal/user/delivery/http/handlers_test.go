@@ -0,0 +1,305 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/config"
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/middlewares"
+	"github.com/Lidne/praktika_MAI/internal/models"
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+const passwordHash = "$2a$10$liWBTeyWFf4xp.R6UFVpFeN8ITgWfXrZ7..nMTeQk07z23FenWnr2"
+
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Errorf(template string, args ...interface{}) {}
+
+// fakeUsers in-memory user repository
+type fakeUsers struct {
+	users map[int]models.User
+}
+
+func (r *fakeUsers) Create(ctx context.Context, user *models.User) error {
+	user.ID = 100
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *fakeUsers) Update(ctx context.Context, user *models.User) error {
+	if _, ok := r.users[user.ID]; !ok {
+		return pgx.ErrNoRows
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *fakeUsers) GetByID(ctx context.Context, id string) (*models.User, error) {
+	userID, _ := strconv.Atoi(id)
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &user, nil
+}
+
+func (r *fakeUsers) GetByLogin(ctx context.Context, login string) (*models.User, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func (r *fakeUsers) FindAll(ctx context.Context, query *pagination.Query, filter *models.UserFilter) (*models.UsersList, error) {
+	list := &models.UsersList{Users: []models.User{}}
+	for _, user := range r.users {
+		list.Users = append(list.Users, user)
+	}
+	sort.Slice(list.Users, func(i, j int) bool { return list.Users[i].ID < list.Users[j].ID })
+	return list, nil
+}
+
+func (r *fakeUsers) Delete(ctx context.Context, id int) error {
+	if _, ok := r.users[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// fakeSessions session repository recording the users whose sessions were ended
+type fakeSessions struct {
+	ended []int
+}
+
+func (r *fakeSessions) Create(ctx context.Context, session *models.Session, ttl time.Duration) error {
+	return nil
+}
+
+func (r *fakeSessions) Touch(ctx context.Context, id string, ttl time.Duration) (*models.Session, error) {
+	return nil, nil
+}
+
+func (r *fakeSessions) GetByID(ctx context.Context, id string) (*models.Session, error) {
+	return nil, nil
+}
+
+func (r *fakeSessions) ListByUser(ctx context.Context, userID int) ([]models.Session, error) {
+	return nil, nil
+}
+
+func (r *fakeSessions) Delete(ctx context.Context, session *models.Session) error {
+	return nil
+}
+
+func (r *fakeSessions) DeleteByUser(ctx context.Context, userID int) error {
+	r.ended = append(r.ended, userID)
+	return nil
+}
+
+var (
+	admin = &auth.Claims{ID: 1, Login: "admin", Role: auth.RoleAdmin}
+	bob   = &auth.Claims{ID: 2, Login: "bob", Role: auth.RoleUser}
+)
+
+func TestUserHandlers(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     *auth.Claims
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   []string
+		wantEnded  []int
+	}{
+		{
+			name:       "list",
+			claims:     admin,
+			method:     http.MethodGet,
+			target:     "/api/users",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"login":"admin"`, `"login":"bob"`},
+		},
+		{
+			name:       "list by a buyer",
+			claims:     bob,
+			method:     http.MethodGet,
+			target:     "/api/users",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "get own profile",
+			claims:     bob,
+			method:     http.MethodGet,
+			target:     "/api/users/2",
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"id":2`, `"login":"bob"`, `"is_admin":false`},
+		},
+		{
+			name:       "get another user",
+			claims:     bob,
+			method:     http.MethodGet,
+			target:     "/api/users/1",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "get missing",
+			claims:     admin,
+			method:     http.MethodGet,
+			target:     "/api/users/42",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "create",
+			claims:     admin,
+			method:     http.MethodPost,
+			target:     "/api/users",
+			body:       `{"name": "Carol", "login": "carol", "password": "secret-password"}`,
+			wantStatus: http.StatusCreated,
+			wantBody:   []string{`"id":100`, `"login":"carol"`},
+		},
+		{
+			name:       "create with a short password",
+			claims:     admin,
+			method:     http.MethodPost,
+			target:     "/api/users",
+			body:       `{"name": "Carol", "login": "carol", "password": "short"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "update own name",
+			claims:     bob,
+			method:     http.MethodPut,
+			target:     "/api/users/2",
+			body:       `{"name": "Robert", "login": "bob"}`,
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"name":"Robert"`},
+		},
+		{
+			name:       "update own password ends sessions",
+			claims:     bob,
+			method:     http.MethodPut,
+			target:     "/api/users/2",
+			body:       `{"name": "Bob", "login": "bob", "password": "new-password"}`,
+			wantStatus: http.StatusOK,
+			wantEnded:  []int{2},
+		},
+		{
+			name:       "promote self",
+			claims:     bob,
+			method:     http.MethodPut,
+			target:     "/api/users/2",
+			body:       `{"name": "Bob", "login": "bob", "is_admin": true}`,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "promote by an admin ends sessions",
+			claims:     admin,
+			method:     http.MethodPut,
+			target:     "/api/users/2",
+			body:       `{"name": "Bob", "login": "bob", "is_admin": true}`,
+			wantStatus: http.StatusOK,
+			wantBody:   []string{`"is_admin":true`},
+			wantEnded:  []int{2},
+		},
+		{
+			name:       "delete",
+			claims:     admin,
+			method:     http.MethodDelete,
+			target:     "/api/users/2",
+			wantStatus: http.StatusNoContent,
+			wantEnded:  []int{2},
+		},
+		{
+			name:       "delete missing",
+			claims:     admin,
+			method:     http.MethodDelete,
+			target:     "/api/users/42",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{users: map[int]models.User{
+				1: {ID: 1, Name: "Admin", Login: "admin", Password: passwordHash, IsAdmin: true},
+				2: {ID: 2, Name: "Bob", Login: "bob", Password: passwordHash},
+			}}
+			sessions := &fakeSessions{}
+			e := echo.New()
+			e.HTTPErrorHandler = httpErrors.NewHTTPErrorHandler(nopLogger{})
+			api := e.Group("/api", func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					claims := *tt.claims
+					auth.SetClaims(c, &claims)
+					return next(c)
+				}
+			})
+			NewRouter(api, users, sessions, validator.New(), middlewares.NewMiddlewareManager(nopLogger{}, &config.Config{}, sessions, users))
+
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			body := rec.Body.String()
+			for _, want := range tt.wantBody {
+				if !strings.Contains(body, want) {
+					t.Errorf("body %s does not contain %s", body, want)
+				}
+			}
+			if strings.Contains(body, `"password"`) || strings.Contains(body, "$2a$") {
+				t.Errorf("body leaks a password: %s", body)
+			}
+			if len(sessions.ended) != len(tt.wantEnded) || len(tt.wantEnded) > 0 && sessions.ended[0] != tt.wantEnded[0] {
+				t.Errorf("ended sessions of %v, want %v", sessions.ended, tt.wantEnded)
+			}
+		})
+	}
+}
+
+func TestCreateUserHashesPassword(t *testing.T) {
+	users := &fakeUsers{users: map[int]models.User{}}
+	e := echo.New()
+	e.HTTPErrorHandler = httpErrors.NewHTTPErrorHandler(nopLogger{})
+	api := e.Group("/api", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			auth.SetClaims(c, &auth.Claims{ID: 1, Role: auth.RoleAdmin})
+			return next(c)
+		}
+	})
+	NewRouter(api, users, &fakeSessions{}, validator.New(), middlewares.NewMiddlewareManager(nopLogger{}, &config.Config{}, nil, users))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/users", strings.NewReader(`{"name": "Carol", "login": "carol", "password": "secret-password"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+	}
+
+	created := users.users[100]
+	if created.Password == "secret-password" || !strings.HasPrefix(created.Password, "$2a$") {
+		t.Errorf("stored password = %q, want a bcrypt hash", created.Password)
+	}
+	if err := created.ComparePasswords("secret-password"); err != nil {
+		t.Errorf("ComparePasswords() error = %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/internal/auth"
+	"github.com/Lidne/praktika_MAI/internal/middlewares"
+	"github.com/Lidne/praktika_MAI/internal/session"
+	"github.com/Lidne/praktika_MAI/internal/user"
+)
+
+// NewRouter register user routes
+func NewRouter(e *echo.Group, users user.UserRepository, sessions session.SessionRepository, validate *validator.Validate, mw middlewares.MiddlewareManager) {
+	h := &handlers{users: users, sessions: sessions, validate: validate}
+	usersGroup := e.Group("/users")
+	usersGroup.GET("", h.getUsers, mw.RequirePermission(auth.PermUsersRead))
+	usersGroup.GET("/:id", h.getUserById)
+	usersGroup.POST("", h.createUser, mw.RequirePermission(auth.PermUsersWrite))
+	usersGroup.PUT("/:id", h.updateUser)
+	usersGroup.DELETE("/:id", h.deleteUser, mw.RequirePermission(auth.PermUsersWrite))
+}
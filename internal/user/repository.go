@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
 )
 
 // UserRepository Sell
@@ -11,6 +12,7 @@ type UserRepository interface {
 	Create(ctx context.Context, product *models.User) error
 	Update(ctx context.Context, product *models.User) error
 	GetByID(ctx context.Context, id string) (*models.User, error)
-	FindAll(ctx context.Context) ([]models.User, error)
+	GetByLogin(ctx context.Context, login string) (*models.User, error)
+	FindAll(ctx context.Context, query *pagination.Query, filter *models.UserFilter) (*models.UsersList, error)
 	Delete(ctx context.Context, id int) error
 }
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/user"
+	"github.com/Lidne/praktika_MAI/pkg/cache"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+const userCacheName = "users"
+
+// userCacheRepo read-through cache in front of another user repository. Password hashes are never cached,
+// users returned by GetByID and FindAll have an empty Password, GetByLogin reads it from the next repository
+type userCacheRepo struct {
+	next    user.UserRepository
+	cache   *cache.Cache
+	ttl     time.Duration
+	listTTL time.Duration
+}
+
+// NewUserCacheRepo userCacheRepo constructor
+func NewUserCacheRepo(next user.UserRepository, client cache.Client, log logger.Logger, ttl, listTTL time.Duration) user.UserRepository {
+	return &userCacheRepo{next: next, cache: cache.NewCache(client, log, userCacheName), ttl: ttl, listTTL: listTTL}
+}
+
+func (r *userCacheRepo) Create(ctx context.Context, user *models.User) error {
+	if err := r.next.Create(ctx, user); err != nil {
+		return err
+	}
+	r.cache.Invalidate(ctx)
+	return nil
+}
+
+func (r *userCacheRepo) Update(ctx context.Context, user *models.User) error {
+	if err := r.next.Update(ctx, user); err != nil {
+		return err
+	}
+	r.cache.Invalidate(ctx, strconv.Itoa(user.ID))
+	return nil
+}
+
+func (r *userCacheRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
+	key := r.cache.Key(id)
+	user := &models.User{}
+	if r.cache.Get(ctx, key, user) {
+		return user, nil
+	}
+
+	version, versionErr := r.cache.Version(ctx)
+	user, err := r.next.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	if versionErr == nil {
+		r.cache.SetFresh(ctx, version, key, user, r.ttl)
+	}
+	return user, nil
+}
+
+// GetByLogin is not cached, login checks must always see the current password hash
+func (r *userCacheRepo) GetByLogin(ctx context.Context, login string) (*models.User, error) {
+	return r.next.GetByLogin(ctx, login)
+}
+
+func (r *userCacheRepo) FindAll(ctx context.Context, query *pagination.Query, filter *models.UserFilter) (*models.UsersList, error) {
+	key, err := r.cache.ListKey(ctx, query, filter)
+	if err != nil {
+		return r.next.FindAll(ctx, query, filter)
+	}
+	list := &models.UsersList{}
+	if r.cache.Get(ctx, key, list) {
+		return list, nil
+	}
+
+	list, err = r.next.FindAll(ctx, query, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Users {
+		list.Users[i].Password = ""
+	}
+	r.cache.Set(ctx, key, list, r.listTTL)
+	return list, nil
+}
+
+func (r *userCacheRepo) Delete(ctx context.Context, id int) error {
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.cache.Invalidate(ctx, strconv.Itoa(id))
+	return nil
+}
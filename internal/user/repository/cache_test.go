@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/pkg/cache/cachetest"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+const passwordHash = "$2a$10$hash"
+
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Warnf(template string, args ...interface{}) {}
+
+// fakeUsers user repository counting the reads that reach it
+type fakeUsers struct {
+	users  map[int]models.User
+	gets   int
+	finds  int
+	logins int
+}
+
+func (r *fakeUsers) Create(ctx context.Context, user *models.User) error {
+	for id := range r.users {
+		if id >= user.ID {
+			user.ID = id + 1
+		}
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *fakeUsers) Update(ctx context.Context, user *models.User) error {
+	if _, ok := r.users[user.ID]; !ok {
+		return pgx.ErrNoRows
+	}
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *fakeUsers) GetByID(ctx context.Context, id string) (*models.User, error) {
+	r.gets++
+	userID, _ := strconv.Atoi(id)
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return &user, nil
+}
+
+func (r *fakeUsers) GetByLogin(ctx context.Context, login string) (*models.User, error) {
+	r.logins++
+	for _, user := range r.users {
+		if user.Login == login {
+			return &user, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
+func (r *fakeUsers) FindAll(ctx context.Context, query *pagination.Query, filter *models.UserFilter) (*models.UsersList, error) {
+	r.finds++
+	list := &models.UsersList{Users: []models.User{}}
+	for _, user := range r.users {
+		list.Users = append(list.Users, user)
+	}
+	sort.Slice(list.Users, func(i, j int) bool { return list.Users[i].ID < list.Users[j].ID })
+	return list, nil
+}
+
+func (r *fakeUsers) Delete(ctx context.Context, id int) error {
+	if _, ok := r.users[id]; !ok {
+		return pgx.ErrNoRows
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func newCachedUsers() (*fakeUsers, *userCacheRepo, *cachetest.Client) {
+	next := &fakeUsers{users: map[int]models.User{
+		1: {ID: 1, Name: "alice", Login: "alice", Password: passwordHash},
+		2: {ID: 2, Name: "bob", Login: "bob", Password: passwordHash, IsAdmin: true},
+	}}
+	client := cachetest.NewClient()
+	repo := NewUserCacheRepo(next, client, nopLogger{}, time.Minute, time.Second).(*userCacheRepo)
+	return next, repo, client
+}
+
+func TestUserCacheStripsPassword(t *testing.T) {
+	next, repo, client := newCachedUsers()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		user, err := repo.GetByID(ctx, "1")
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if user.Login != "alice" || user.Password != "" {
+			t.Errorf("GetByID() = %+v, want alice without a password", user)
+		}
+	}
+	if next.gets != 1 {
+		t.Errorf("next GetByID called %d times, want 1", next.gets)
+	}
+
+	for i := 0; i < 2; i++ {
+		list, err := repo.FindAll(ctx, &pagination.Query{Sort: "id", Limit: 10}, &models.UserFilter{})
+		if err != nil {
+			t.Fatalf("FindAll() error = %v", err)
+		}
+		for _, user := range list.Users {
+			if user.Password != "" {
+				t.Errorf("FindAll() user %d has a password", user.ID)
+			}
+		}
+	}
+	if next.finds != 1 {
+		t.Errorf("next FindAll called %d times, want 1", next.finds)
+	}
+
+	if next.users[1].Password != passwordHash {
+		t.Error("stripping the password changed the user of the next repository")
+	}
+	values := client.Values()
+	if len(values) == 0 {
+		t.Fatal("nothing is cached")
+	}
+	for key, v := range values {
+		if strings.Contains(v, passwordHash) {
+			t.Errorf("%s holds the password hash: %s", key, v)
+		}
+	}
+}
+
+func TestUserCacheGetByLogin(t *testing.T) {
+	next, repo, client := newCachedUsers()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		user, err := repo.GetByLogin(ctx, "alice")
+		if err != nil {
+			t.Fatalf("GetByLogin() error = %v", err)
+		}
+		if user.Password != passwordHash {
+			t.Errorf("GetByLogin() password = %q, want the hash", user.Password)
+		}
+	}
+	if next.logins != 2 {
+		t.Errorf("next GetByLogin called %d times, want every call", next.logins)
+	}
+	if values := client.Values(); len(values) != 0 {
+		t.Errorf("GetByLogin cached %v", values)
+	}
+}
+
+func TestUserCacheInvalidation(t *testing.T) {
+	next, repo, _ := newCachedUsers()
+	ctx := context.Background()
+	query := &pagination.Query{Sort: "id", Limit: 10}
+
+	if _, err := repo.GetByID(ctx, "1"); err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if _, err := repo.FindAll(ctx, query, &models.UserFilter{}); err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+
+	if err := repo.Update(ctx, &models.User{ID: 1, Name: "alice cooper", Login: "alice", Password: passwordHash}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	user, err := repo.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if user.Name != "alice cooper" {
+		t.Errorf("GetByID() = %+v after Update, want the new name", user)
+	}
+	list, err := repo.FindAll(ctx, query, &models.UserFilter{})
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if list.Users[0].Name != "alice cooper" {
+		t.Errorf("FindAll() = %+v after Update, want the new name", list.Users)
+	}
+
+	if err := repo.Delete(ctx, 1); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if user, err := repo.GetByID(ctx, "1"); err == nil {
+		t.Errorf("GetByID() = %+v after Delete, want error", user)
+	}
+	list, err = repo.FindAll(ctx, query, &models.UserFilter{})
+	if err != nil {
+		t.Fatalf("FindAll() error = %v", err)
+	}
+	if len(list.Users) != 1 || list.Users[0].ID != 2 {
+		t.Errorf("FindAll() = %+v after Delete, want bob only", list.Users)
+	}
+	if next.finds != 3 {
+		t.Errorf("next FindAll called %d times, want every list after a change", next.finds)
+	}
+}
@@ -2,13 +2,26 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"strconv"
+
 	"github.com/Lidne/praktika_MAI/internal/models"
+	"github.com/Lidne/praktika_MAI/internal/outbox"
+	outboxRepo "github.com/Lidne/praktika_MAI/internal/outbox/repository"
 	"github.com/Lidne/praktika_MAI/internal/user"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+	"github.com/jackc/pgx/v5"
+	"github.com/pkg/errors"
 
 	"github.com/Lidne/praktika_MAI/pkg/postgres"
 )
 
+var userSortColumns = map[string]postgres.SortColumn{
+	"id":         {Column: "id", Type: "integer"},
+	"name":       {Column: "name", Type: "text"},
+	"login":      {Column: "login", Type: "text"},
+	"updated_at": {Column: "updatedat", Type: "timestamp"},
+}
+
 // userRepo
 type userRepo struct {
 	client postgres.Client
@@ -19,64 +32,112 @@ func NewUserRepo(client postgres.Client) user.UserRepository {
 	return &userRepo{client: client}
 }
 
-func (r *userRepo) Create(ctx context.Context, user *models.User) error {
-	q := `INSERT INTO users (name, login, password) VALUES ($1, $2, $3) returning id`
-	if err := r.client.QueryRow(ctx, q, user.Name, user.Login, user.Password).Scan(&user.ID); err != nil {
-		fmt.Errorf(fmt.Sprintf("SQL Error. Falied to create user: %s", err.Error()))
-		return err
-	}
+func (r *userRepo) Create(ctx context.Context, user *models.User) (err error) {
+	span, ctx := postgres.StartSpan(ctx, "userRepo.Create")
+	defer postgres.Finish(span, &err)
 
-	return nil
+	return pgx.BeginFunc(ctx, r.client, func(tx pgx.Tx) error {
+		q := `INSERT INTO users (name, login, password, isadmin) VALUES ($1, $2, $3, $4) returning id, updatedat`
+		if err := tx.QueryRow(ctx, q, user.Name, user.Login, user.Password, user.IsAdmin).Scan(&user.ID, &user.UpdatedAt); err != nil {
+			return errors.Wrap(err, "userRepo.Create.QueryRow")
+		}
+		return outboxRepo.Write(ctx, tx, outbox.AggregateUser, strconv.Itoa(user.ID), outbox.EventCreated, user.Response())
+	})
 }
 
-func (r *userRepo) Update(ctx context.Context, user *models.User) error {
-	q := `UPDATE users SET name=$1, login=$2, password=$3 WHERE id=$4`
-	_, err := r.client.Exec(ctx, q, &user.Name, &user.Login, &user.Password, &user.ID)
-	if err != nil {
-		fmt.Errorf("SQL Error. Failed to update user: %s", err.Error())
-		return err
-	}
-	return nil
+func (r *userRepo) Update(ctx context.Context, user *models.User) (err error) {
+	span, ctx := postgres.StartSpan(ctx, "userRepo.Update")
+	defer postgres.Finish(span, &err)
+
+	return pgx.BeginFunc(ctx, r.client, func(tx pgx.Tx) error {
+		// an empty password keeps the current hash, users read through the cache have none
+		q := `UPDATE users SET name=$1, login=$2, password=COALESCE(NULLIF($3, ''), password), isadmin=$4 WHERE id=$5 returning updatedat`
+		if err := tx.QueryRow(ctx, q, &user.Name, &user.Login, &user.Password, &user.IsAdmin, &user.ID).Scan(&user.UpdatedAt); err != nil {
+			return errors.Wrap(err, "userRepo.Update.QueryRow")
+		}
+		return outboxRepo.Write(ctx, tx, outbox.AggregateUser, strconv.Itoa(user.ID), outbox.EventUpdated, user.Response())
+	})
 }
 
-func (r *userRepo) GetByID(ctx context.Context, id string) (*models.User, error) {
+func (r *userRepo) GetByID(ctx context.Context, id string) (_ *models.User, err error) {
+	span, ctx := postgres.StartSpan(ctx, "userRepo.GetByID")
+	defer postgres.Finish(span, &err)
+
 	q := `SELECT id, name, updatedat, login, password, isadmin FROM users WHERE id=$1`
 	user := &models.User{}
 	if err := r.client.QueryRow(ctx, q, id).Scan(&user.ID, &user.Name, &user.UpdatedAt, &user.Login, &user.Password, &user.IsAdmin); err != nil {
-		fmt.Errorf("SQL Error. Failed to get user by ID: %s", err.Error())
-		return nil, err
+		return nil, errors.Wrap(err, "userRepo.GetByID.QueryRow")
+	}
+	return user, nil
+}
+
+func (r *userRepo) GetByLogin(ctx context.Context, login string) (_ *models.User, err error) {
+	span, ctx := postgres.StartSpan(ctx, "userRepo.GetByLogin")
+	defer postgres.Finish(span, &err)
+
+	q := `SELECT id, name, updatedat, login, password, isadmin FROM users WHERE login=$1`
+	user := &models.User{}
+	if err := r.client.QueryRow(ctx, q, login).Scan(&user.ID, &user.Name, &user.UpdatedAt, &user.Login, &user.Password, &user.IsAdmin); err != nil {
+		return nil, errors.Wrap(err, "userRepo.GetByLogin.QueryRow")
 	}
 	return user, nil
 }
 
-func (r *userRepo) FindAll(ctx context.Context) ([]models.User, error) {
-	q := `SELECT id, name, updatedat, login, password, isadmin FROM users`
-	rows, err := r.client.Query(ctx, q)
+func (r *userRepo) FindAll(ctx context.Context, query *pagination.Query, filter *models.UserFilter) (_ *models.UsersList, err error) {
+	span, ctx := postgres.StartSpan(ctx, "userRepo.FindAll")
+	defer postgres.Finish(span, &err)
+
+	col, ok := userSortColumns[query.Sort]
+	if !ok {
+		return nil, errors.Wrap(pagination.ErrInvalidSort, query.Sort)
+	}
+
+	b := &postgres.Builder{}
+	if filter.IsAdmin != nil {
+		b.Where("isadmin = " + b.Arg(*filter.IsAdmin))
+	}
+	q := b.Keyset(`SELECT id, name, updatedat, login, password, isadmin, `+col.Column+`::text FROM users`, col, query)
+
+	rows, err := r.client.Query(ctx, q, b.Args()...)
 	if err != nil {
-		fmt.Errorf("SQL Error. Failed to find all users: %s", err.Error())
-		return nil, err
+		return nil, errors.Wrap(err, "userRepo.FindAll.Query")
 	}
 	defer rows.Close()
 
-	users := []models.User{}
+	list := &models.UsersList{Users: []models.User{}}
+	var sortKey string
 	for rows.Next() {
+		if len(list.Users) == query.Limit {
+			last := list.Users[len(list.Users)-1]
+			list.NextCursor = query.Next(sortKey, last.ID)
+			break
+		}
 		user := models.User{}
-		if err := rows.Scan(&user.ID, &user.Name, &user.UpdatedAt, &user.Login, &user.Password, &user.IsAdmin); err != nil {
-			fmt.Errorf("SQL Error. Failed to scan user: %s", err.Error())
-			return nil, err
+		if err := rows.Scan(&user.ID, &user.Name, &user.UpdatedAt, &user.Login, &user.Password, &user.IsAdmin, &sortKey); err != nil {
+			return nil, errors.Wrap(err, "userRepo.FindAll.Scan")
 		}
-		users = append(users, user)
+		list.Users = append(list.Users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "userRepo.FindAll.rows")
 	}
 
-	return users, nil
+	return list, nil
 }
 
-func (r *userRepo) Delete(ctx context.Context, id int) error {
-	q := `DELETE FROM users WHERE id=$1`
-	_, err := r.client.Exec(ctx, q, id)
-	if err != nil {
-		fmt.Errorf("SQL Error. Failed to delete user: %s", err.Error())
-		return err
-	}
-	return nil
+func (r *userRepo) Delete(ctx context.Context, id int) (err error) {
+	span, ctx := postgres.StartSpan(ctx, "userRepo.Delete")
+	defer postgres.Finish(span, &err)
+
+	return pgx.BeginFunc(ctx, r.client, func(tx pgx.Tx) error {
+		q := `DELETE FROM users WHERE id=$1`
+		tag, err := tx.Exec(ctx, q, id)
+		if err != nil {
+			return errors.Wrap(err, "userRepo.Delete.Exec")
+		}
+		if tag.RowsAffected() == 0 {
+			return errors.Wrap(pgx.ErrNoRows, "userRepo.Delete")
+		}
+		return outboxRepo.Write(ctx, tx, outbox.AggregateUser, strconv.Itoa(id), outbox.EventDeleted, models.UserResponse{ID: id})
+	})
 }
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS bargains;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS users;
+DROP FUNCTION IF EXISTS set_updatedat();
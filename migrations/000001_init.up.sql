@@ -0,0 +1,41 @@
+CREATE FUNCTION set_updatedat() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updatedat = now() AT TIME ZONE 'UTC';
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE users
+(
+    id        SERIAL PRIMARY KEY,
+    name      VARCHAR(255) NOT NULL,
+    login     VARCHAR(255) NOT NULL UNIQUE,
+    password  VARCHAR(255) NOT NULL,
+    isadmin   BOOLEAN      NOT NULL DEFAULT FALSE,
+    updatedat TIMESTAMP    NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')
+);
+
+CREATE TABLE products
+(
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    price       INTEGER      NOT NULL CHECK (price >= 0),
+    updatedat   TIMESTAMP    NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')
+);
+
+CREATE TABLE bargains
+(
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INTEGER   NOT NULL REFERENCES users (id),
+    product_id INTEGER   NOT NULL REFERENCES products (id),
+    updatedat  TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')
+);
+
+CREATE INDEX bargains_updatedat_idx ON bargains (updatedat, id);
+CREATE INDEX bargains_user_id_idx ON bargains (user_id, id);
+CREATE INDEX bargains_product_id_idx ON bargains (product_id, id);
+
+CREATE TRIGGER users_updatedat BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION set_updatedat();
+CREATE TRIGGER products_updatedat BEFORE UPDATE ON products FOR EACH ROW EXECUTE FUNCTION set_updatedat();
+CREATE TRIGGER bargains_updatedat BEFORE UPDATE ON bargains FOR EACH ROW EXECUTE FUNCTION set_updatedat();
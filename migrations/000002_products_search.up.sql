@@ -0,0 +1,3 @@
+CREATE INDEX products_search_idx ON products USING GIN (
+    (setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', description), 'B'))
+);
@@ -0,0 +1,12 @@
+CREATE TABLE outbox
+(
+    id           BIGSERIAL PRIMARY KEY,
+    aggregate    VARCHAR(64)  NOT NULL,
+    aggregate_id VARCHAR(64)  NOT NULL,
+    event_type   VARCHAR(128) NOT NULL,
+    payload      JSONB        NOT NULL,
+    created_at   TIMESTAMP    NOT NULL DEFAULT (now() AT TIME ZONE 'UTC'),
+    sent_at      TIMESTAMP
+);
+
+CREATE INDEX outbox_unsent_idx ON outbox (id) WHERE sent_at IS NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS outbox_sent_at_idx;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS claimed_until;
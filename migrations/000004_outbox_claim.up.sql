@@ -0,0 +1,3 @@
+ALTER TABLE outbox ADD COLUMN claimed_until TIMESTAMP;
+
+CREATE INDEX outbox_sent_at_idx ON outbox (sent_at) WHERE sent_at IS NOT NULL;
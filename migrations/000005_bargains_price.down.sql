@@ -0,0 +1 @@
+ALTER TABLE bargains DROP COLUMN IF EXISTS price;
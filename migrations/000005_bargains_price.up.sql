@@ -0,0 +1,6 @@
+ALTER TABLE bargains ADD COLUMN price INTEGER;
+
+UPDATE bargains b SET price = p.price FROM products p WHERE p.id = b.product_id;
+
+ALTER TABLE bargains ALTER COLUMN price SET NOT NULL;
+ALTER TABLE bargains ADD CONSTRAINT bargains_price_check CHECK (price >= 0);
@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+// FS versioned schema migrations, files are named <version>_<name>.up.sql and <version>_<name>.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
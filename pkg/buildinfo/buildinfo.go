@@ -0,0 +1,45 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Version of the build, set by the linker:
+// go build -ldflags "-X github.com/Lidne/praktika_MAI/pkg/buildinfo.Version=v1.2.3" ./cmd
+var Version = "dev"
+
+// Info what the running binary was built from
+type Info struct {
+	Version      string `json:"version"`
+	AppVersion   string `json:"app_version"`
+	GoVersion    string `json:"go_version"`
+	Revision     string `json:"vcs_revision,omitempty"`
+	RevisionTime string `json:"vcs_time,omitempty"`
+	Modified     bool   `json:"vcs_modified"`
+}
+
+// Get build info of the binary, appVersion is the version from the config
+func Get(appVersion string) Info {
+	info := Info{
+		Version:    Version,
+		AppVersion: appVersion,
+		GoVersion:  runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.RevisionTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+var (
+	cacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_hits_total",
+		Help: "The total number of read-through cache hits",
+	}, []string{"cache"})
+	cacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_misses_total",
+		Help: "The total number of read-through cache misses",
+	}, []string{"cache"})
+)
+
+// Client redis commands used by Cache, implemented by *redis.Client
+type Client interface {
+	Get(ctx context.Context, key string) *redis.StringCmd
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Del(ctx context.Context, keys ...string) *redis.IntCmd
+	Incr(ctx context.Context, key string) *redis.IntCmd
+}
+
+// Cache json values in redis under a common key prefix.
+// Redis errors are logged and reported as misses, the cache never fails a lookup on its own.
+type Cache struct {
+	client Client
+	log    logger.Logger
+	name   string
+}
+
+// NewCache constructor, name is used as key prefix and metrics label
+func NewCache(client Client, log logger.Logger, name string) *Cache {
+	return &Cache{client: client, log: log, name: name}
+}
+
+// Key key of a single entity
+func (c *Cache) Key(id string) string {
+	return c.name + ":" + id
+}
+
+// Version current version, every Invalidate changes it. Read it before loading a value for SetFresh
+func (c *Cache) Version(ctx context.Context) (int64, error) {
+	version, err := c.client.Get(ctx, c.versionKey()).Int64()
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// ListKey key of a list query, params are hashed together with the current list version
+// so that Invalidate drops every cached page at once. A page loaded before an Invalidate is stored
+// under the old version and never read
+func (c *Cache) ListKey(ctx context.Context, params ...interface{}) (string, error) {
+	version, err := c.Version(ctx)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	sum := sha1.Sum(b)
+	return c.name + ":list:" + strconv.FormatInt(version, 10) + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// Get unmarshal cached value into dest, returns false on miss
+func (c *Cache) Get(ctx context.Context, key string, dest interface{}) bool {
+	b, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			logger.FromContext(ctx, c.log).Warnf("cache.Get %s: %v", key, err)
+		}
+		cacheMisses.WithLabelValues(c.name).Inc()
+		return false
+	}
+	if err := json.Unmarshal(b, dest); err != nil {
+		logger.FromContext(ctx, c.log).Warnf("cache.Get json.Unmarshal %s: %v", key, err)
+		cacheMisses.WithLabelValues(c.name).Inc()
+		return false
+	}
+	cacheHits.WithLabelValues(c.name).Inc()
+	return true
+}
+
+// Set store value for ttl
+func (c *Cache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		logger.FromContext(ctx, c.log).Warnf("cache.Set json.Marshal %s: %v", key, err)
+		return
+	}
+	if err := c.client.Set(ctx, key, b, ttl).Err(); err != nil {
+		logger.FromContext(ctx, c.log).Warnf("cache.Set %s: %v", key, err)
+	}
+}
+
+// SetFresh store value for ttl unless Invalidate was called after version was read, so that a value loaded
+// before a concurrent change is not cached after it. The check and the write are not atomic: when Invalidate
+// runs entirely between them, the stale value is kept until ttl expires
+func (c *Cache) SetFresh(ctx context.Context, version int64, key string, value interface{}, ttl time.Duration) {
+	current, err := c.Version(ctx)
+	if err != nil {
+		logger.FromContext(ctx, c.log).Warnf("cache.SetFresh %s: %v", key, err)
+		return
+	}
+	if current != version {
+		return
+	}
+	c.Set(ctx, key, value, ttl)
+}
+
+// Invalidate delete entities by id and every cached list. The version is changed first,
+// so that SetFresh calls checking it after that skip the write and earlier ones are deleted
+func (c *Cache) Invalidate(ctx context.Context, ids ...string) {
+	if err := c.client.Incr(ctx, c.versionKey()).Err(); err != nil {
+		logger.FromContext(ctx, c.log).Warnf("cache.Invalidate Incr: %v", err)
+	}
+	if len(ids) > 0 {
+		keys := make([]string, 0, len(ids))
+		for _, id := range ids {
+			keys = append(keys, c.Key(id))
+		}
+		if err := c.client.Del(ctx, keys...).Err(); err != nil {
+			logger.FromContext(ctx, c.log).Warnf("cache.Invalidate Del: %v", err)
+		}
+	}
+}
+
+func (c *Cache) versionKey() string {
+	return c.name + ":list:version"
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Lidne/praktika_MAI/pkg/cache/cachetest"
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+// nopLogger discards the warnings logged by the cache
+type nopLogger struct {
+	logger.Logger
+}
+
+func (nopLogger) Warnf(template string, args ...interface{}) {}
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     string
+		redisErr   error
+		wantOK     bool
+		wantHits   float64
+		wantMisses float64
+	}{
+		{name: "hit", stored: `{"name": "tea"}`, wantOK: true, wantHits: 1},
+		{name: "miss", wantMisses: 1},
+		{name: "broken value", stored: `{"name": `, wantMisses: 1},
+		{name: "redis down", stored: `{"name": "tea"}`, redisErr: errors.New("connection refused"), wantMisses: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := cachetest.NewClient()
+			name := "get " + tt.name
+			c := NewCache(client, nopLogger{}, name)
+			if tt.stored != "" {
+				client.Set(context.Background(), c.Key("1"), tt.stored, 0)
+			}
+			client.Err = tt.redisErr
+
+			var got item
+			ok := c.Get(context.Background(), c.Key("1"), &got)
+			if ok != tt.wantOK {
+				t.Fatalf("Get() = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got.Name != "tea" {
+				t.Errorf("Get() value = %+v, want tea", got)
+			}
+			if hits := testutil.ToFloat64(cacheHits.WithLabelValues(name)); hits != tt.wantHits {
+				t.Errorf("hits = %v, want %v", hits, tt.wantHits)
+			}
+			if misses := testutil.ToFloat64(cacheMisses.WithLabelValues(name)); misses != tt.wantMisses {
+				t.Errorf("misses = %v, want %v", misses, tt.wantMisses)
+			}
+		})
+	}
+}
+
+func TestSetGet(t *testing.T) {
+	client := cachetest.NewClient()
+	c := NewCache(client, nopLogger{}, "items")
+	ctx := context.Background()
+
+	c.Set(ctx, c.Key("1"), item{Name: "tea"}, time.Minute)
+	if client.TTL("items:1") != time.Minute {
+		t.Errorf("ttl = %v, want %v", client.TTL("items:1"), time.Minute)
+	}
+	var got item
+	if !c.Get(ctx, c.Key("1"), &got) || got.Name != "tea" {
+		t.Errorf("Get() = %+v, want tea", got)
+	}
+}
+
+func TestListKey(t *testing.T) {
+	client := cachetest.NewClient()
+	c := NewCache(client, nopLogger{}, "items")
+	ctx := context.Background()
+
+	key := func(params ...interface{}) string {
+		t.Helper()
+		k, err := c.ListKey(ctx, params...)
+		if err != nil {
+			t.Fatalf("ListKey() error = %v", err)
+		}
+		return k
+	}
+
+	first := key(10, "name")
+	if key(10, "name") != first {
+		t.Error("ListKey() differs for the same params")
+	}
+	if key(20, "name") == first {
+		t.Error("ListKey() is the same for other params")
+	}
+
+	c.Invalidate(ctx)
+	if key(10, "name") == first {
+		t.Error("ListKey() did not change after Invalidate")
+	}
+
+	client.Err = errors.New("connection refused")
+	if _, err := c.ListKey(ctx, 10, "name"); err == nil {
+		t.Error("ListKey() error = nil while redis is down")
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	client := cachetest.NewClient()
+	c := NewCache(client, nopLogger{}, "items")
+	ctx := context.Background()
+
+	for _, id := range []string{"1", "2", "3"} {
+		c.Set(ctx, c.Key(id), item{Name: id}, time.Minute)
+	}
+	version, err := c.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+
+	c.Invalidate(ctx, "1", "3")
+	for id, want := range map[string]bool{"1": false, "2": true, "3": false} {
+		if _, ok := client.Value(c.Key(id)); ok != want {
+			t.Errorf("%s cached = %v, want %v", c.Key(id), ok, want)
+		}
+	}
+	if got, _ := c.Version(ctx); got == version {
+		t.Errorf("Version() = %d after Invalidate, want it changed", got)
+	}
+}
+
+func TestSetFresh(t *testing.T) {
+	client := cachetest.NewClient()
+	c := NewCache(client, nopLogger{}, "items")
+	ctx := context.Background()
+
+	version, err := c.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	c.SetFresh(ctx, version, c.Key("1"), item{Name: "tea"}, time.Minute)
+	if _, ok := client.Value(c.Key("1")); !ok {
+		t.Error("SetFresh() did not store a value loaded at the current version")
+	}
+
+	// a change invalidated the cache while the value was loaded
+	c.Invalidate(ctx, "2")
+	c.SetFresh(ctx, version, c.Key("2"), item{Name: "stale"}, time.Minute)
+	if v, ok := client.Value(c.Key("2")); ok {
+		t.Errorf("SetFresh() stored %s loaded before Invalidate", v)
+	}
+}
@@ -0,0 +1,109 @@
+// Package cachetest in-memory redis client for cache tests
+package cachetest
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Client cache.Client keeping values in a map, ttls are recorded but never expire values
+type Client struct {
+	// Err returned by every command when set, as if redis was unavailable
+	Err error
+
+	mu     sync.Mutex
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+// NewClient empty Client
+func NewClient() *Client {
+	return &Client{values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+// Value stored value of key and whether it exists
+func (c *Client) Value(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.values[key]
+	return v, ok
+}
+
+// TTL expiration the key was set with
+func (c *Client) TTL(key string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttls[key]
+}
+
+// Values copy of all stored values by key
+func (c *Client) Values() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make(map[string]string, len(c.values))
+	for k, v := range c.values {
+		values[k] = v
+	}
+	return values
+}
+
+func (c *Client) Get(ctx context.Context, key string) *redis.StringCmd {
+	if c.Err != nil {
+		return redis.NewStringResult("", c.Err)
+	}
+	v, ok := c.Value(key)
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(v, nil)
+}
+
+func (c *Client) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	if c.Err != nil {
+		return redis.NewStatusResult("", c.Err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch v := value.(type) {
+	case []byte:
+		c.values[key] = string(v)
+	default:
+		c.values[key] = fmt.Sprint(v)
+	}
+	c.ttls[key] = expiration
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (c *Client) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	if c.Err != nil {
+		return redis.NewIntResult(0, c.Err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int64
+	for _, key := range keys {
+		if _, ok := c.values[key]; ok {
+			delete(c.values, key)
+			delete(c.ttls, key)
+			n++
+		}
+	}
+	return redis.NewIntResult(n, nil)
+}
+
+func (c *Client) Incr(ctx context.Context, key string) *redis.IntCmd {
+	if c.Err != nil {
+		return redis.NewIntResult(0, c.Err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, _ := strconv.ParseInt(c.values[key], 10, 64)
+	n++
+	c.values[key] = strconv.FormatInt(n, 10)
+	return redis.NewIntResult(n, nil)
+}
@@ -0,0 +1,47 @@
+package csrf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+)
+
+// MakeToken token bound to the session, valid until expiresAt.
+// Format is "<expires unix>.<hex hmac-sha256 of session id and expiry>"
+func MakeToken(secret, sessionID string, expiresAt time.Time) string {
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	return expires + "." + sign(secret, sessionID, expires)
+}
+
+// ValidateToken check that the token was made for the session and is not expired
+func ValidateToken(secret, sessionID, token string) error {
+	if token == "" {
+		return httpErrors.CSRFNotPresented
+	}
+	expires, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return httpErrors.WrongCSRFToken
+	}
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return httpErrors.WrongCSRFToken
+	}
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, sessionID, expires))) {
+		return httpErrors.WrongCSRFToken
+	}
+	if time.Now().Unix() > expiresAt {
+		return httpErrors.ExpiredCSRFError
+	}
+	return nil
+}
+
+func sign(secret, sessionID, expires string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(sessionID + ":" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,79 @@
+package csrf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+)
+
+func TestValidateToken(t *testing.T) {
+	const (
+		secret    = "csrf-test-secret"
+		sessionID = "session-1"
+	)
+	valid := MakeToken(secret, sessionID, time.Now().Add(time.Hour))
+	expires, signature, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name      string
+		secret    string
+		sessionID string
+		token     string
+		want      error
+	}{
+		{name: "valid", secret: secret, sessionID: sessionID, token: valid},
+		{name: "empty", secret: secret, sessionID: sessionID, token: "", want: httpErrors.CSRFNotPresented},
+		{name: "no separator", secret: secret, sessionID: sessionID, token: signature, want: httpErrors.WrongCSRFToken},
+		{name: "bad expiry", secret: secret, sessionID: sessionID, token: "soon." + signature, want: httpErrors.WrongCSRFToken},
+		{
+			name:      "expired",
+			secret:    secret,
+			sessionID: sessionID,
+			token:     MakeToken(secret, sessionID, time.Now().Add(-time.Minute)),
+			want:      httpErrors.ExpiredCSRFError,
+		},
+		{name: "other session", secret: secret, sessionID: "session-2", token: valid, want: httpErrors.WrongCSRFToken},
+		{name: "other secret", secret: "another-secret", sessionID: sessionID, token: valid, want: httpErrors.WrongCSRFToken},
+		{name: "tampered signature", secret: secret, sessionID: sessionID, token: expires + "." + flipLast(signature), want: httpErrors.WrongCSRFToken},
+		{name: "extended expiry", secret: secret, sessionID: sessionID, token: expires + "0." + signature, want: httpErrors.WrongCSRFToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateToken(tt.secret, tt.sessionID, tt.token)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("ValidateToken() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeTokenFormat(t *testing.T) {
+	expiresAt := time.Unix(1700000000, 0)
+	token := MakeToken("secret", "session", expiresAt)
+
+	expires, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		t.Fatalf("MakeToken() = %q, want <expires>.<signature>", token)
+	}
+	if expires != "1700000000" {
+		t.Errorf("expires = %q, want %q", expires, "1700000000")
+	}
+	if len(signature) != 64 {
+		t.Errorf("signature length = %d, want 64 hex chars of sha256", len(signature))
+	}
+	if again := MakeToken("secret", "session", expiresAt); again != token {
+		t.Errorf("MakeToken() is not deterministic: %q != %q", again, token)
+	}
+}
+
+func flipLast(s string) string {
+	last := s[len(s)-1]
+	if last == '0' {
+		return s[:len(s)-1] + "1"
+	}
+	return s[:len(s)-1] + "0"
+}
@@ -0,0 +1,86 @@
+package grpcErrors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	ErrInvalidArgument = errors.New("invalid argument")
+)
+
+// postgres error codes, https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgDataExceptionClass  = "22"
+	pgQueryCanceled       = "57014"
+)
+
+const internalErrorMessage = "Internal Server Error"
+
+// ParseGRPCErrStatusCode map error to grpc status code
+func ParseGRPCErrStatusCode(err error) codes.Code {
+	var pgErr *pgconn.PgError
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, pgx.ErrNoRows):
+		return codes.NotFound
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, ErrInvalidArgument), errors.As(err, &validationErrs):
+		return codes.InvalidArgument
+	case errors.As(err, &pgErr):
+		return parsePgErrorCode(pgErr)
+	}
+	return codes.Internal
+}
+
+func parsePgErrorCode(err *pgconn.PgError) codes.Code {
+	switch {
+	case err.Code == pgUniqueViolation:
+		return codes.AlreadyExists
+	case err.Code == pgForeignKeyViolation:
+		return codes.FailedPrecondition
+	case err.Code == pgNotNullViolation, err.Code == pgCheckViolation, strings.HasPrefix(err.Code, pgDataExceptionClass):
+		return codes.InvalidArgument
+	case err.Code == pgQueryCanceled:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+// ErrorResponse grpc status error with code parsed from err. Details of postgres and unexpected errors
+// are not sent to clients, the caller logs them
+func ErrorResponse(err error, msg string) error {
+	code := ParseGRPCErrStatusCode(err)
+	if code == codes.Internal {
+		return status.Error(codes.Internal, internalErrorMessage)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch code {
+		case codes.AlreadyExists, codes.FailedPrecondition:
+			return status.Error(code, pgErr.Detail)
+		case codes.InvalidArgument:
+			return status.Error(code, pgErr.Message)
+		default:
+			return status.Error(code, code.String())
+		}
+	}
+	return status.Errorf(code, "%s: %v", msg, err)
+}
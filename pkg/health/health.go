@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check probe of a dependency, returns error when the dependency is unavailable
+type Check func(ctx context.Context) error
+
+// ComponentStatus result of one check
+type ComponentStatus struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report results of all checks, Status is up only when every component is up
+type Report struct {
+	Status     string            `json:"status"`
+	Components []ComponentStatus `json:"components"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs checks concurrently, each bounded by timeout, and reuses the report for cacheTTL
+// so that frequent probes do not load the dependencies
+type Checker struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	checks   []namedCheck
+
+	mu     sync.Mutex
+	report *Report
+}
+
+// NewChecker Checker constructor
+func NewChecker(timeout, cacheTTL time.Duration) *Checker {
+	return &Checker{timeout: timeout, cacheTTL: cacheTTL}
+}
+
+// Add check of the named component
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Check cached report or run every check, concurrent callers wait for the same run
+func (c *Checker) Check(ctx context.Context) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.report != nil && time.Since(c.report.CheckedAt) < c.cacheTTL {
+		return c.report
+	}
+
+	report := &Report{Status: StatusUp, Components: make([]ComponentStatus, len(c.checks)), CheckedAt: time.Now().UTC()}
+	wg := &sync.WaitGroup{}
+	for i, nc := range c.checks {
+		wg.Add(1)
+		go func(i int, nc namedCheck) {
+			defer wg.Done()
+			report.Components[i] = c.run(ctx, nc)
+		}(i, nc)
+	}
+	wg.Wait()
+
+	for _, component := range report.Components {
+		if component.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	c.report = report
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, nc namedCheck) ComponentStatus {
+	// the report is cached, a cancelled probe request must not be recorded as a failed component
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := nc.check(ctx)
+	status := ComponentStatus{
+		Name:      nc.name,
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+	return status
+}
@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheckerStatus(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		order      []string
+		wantStatus string
+		wantErrors map[string]string
+	}{
+		{name: "no checks", wantStatus: StatusUp},
+		{
+			name:       "all up",
+			checks:     map[string]Check{"postgres": up, "redis": up},
+			order:      []string{"postgres", "redis"},
+			wantStatus: StatusUp,
+		},
+		{
+			name:       "one down",
+			checks:     map[string]Check{"postgres": up, "kafka": down},
+			order:      []string{"postgres", "kafka"},
+			wantStatus: StatusDown,
+			wantErrors: map[string]string{"kafka": "connection refused"},
+		},
+		{
+			name:       "timeout",
+			checks:     map[string]Check{"redis": slow},
+			order:      []string{"redis"},
+			wantStatus: StatusDown,
+			wantErrors: map[string]string{"redis": context.DeadlineExceeded.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(20*time.Millisecond, 0)
+			for _, name := range tt.order {
+				c.Add(name, tt.checks[name])
+			}
+
+			report := c.Check(context.Background())
+			if report.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			if len(report.Components) != len(tt.order) {
+				t.Fatalf("Components = %+v, want %d", report.Components, len(tt.order))
+			}
+			for i, component := range report.Components {
+				if component.Name != tt.order[i] {
+					t.Errorf("Components[%d].Name = %s, want %s", i, component.Name, tt.order[i])
+				}
+				wantErr := tt.wantErrors[component.Name]
+				wantStatus := StatusUp
+				if wantErr != "" {
+					wantStatus = StatusDown
+				}
+				if component.Status != wantStatus || component.Error != wantErr {
+					t.Errorf("%s = %s %q, want %s %q", component.Name, component.Status, component.Error, wantStatus, wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckerCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		cacheTTL time.Duration
+		wait     time.Duration
+		wantRuns int32
+	}{
+		{name: "no cache", cacheTTL: 0, wantRuns: 2},
+		{name: "within ttl", cacheTTL: time.Hour, wantRuns: 1},
+		{name: "after ttl", cacheTTL: 10 * time.Millisecond, wait: 20 * time.Millisecond, wantRuns: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs atomic.Int32
+			c := NewChecker(time.Second, tt.cacheTTL)
+			c.Add("postgres", func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			})
+
+			first := c.Check(context.Background())
+			time.Sleep(tt.wait)
+			second := c.Check(context.Background())
+
+			if got := runs.Load(); got != tt.wantRuns {
+				t.Errorf("check ran %d times, want %d", got, tt.wantRuns)
+			}
+			if cached := first == second; cached != (tt.wantRuns == 1) {
+				t.Errorf("second report reused = %v, want %v", cached, tt.wantRuns == 1)
+			}
+		})
+	}
+}
+
+func TestCheckerConcurrentCallersShareRun(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	c := NewChecker(time.Second, time.Hour)
+	c.Add("kafka", func(ctx context.Context) error {
+		runs.Add(1)
+		<-release
+		return nil
+	})
+
+	const callers = 8
+	reports := make([]*Report, callers)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reports[i] = c.Check(context.Background())
+		}(i)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := runs.Load(); got != 1 {
+		t.Errorf("check ran %d times, want 1", got)
+	}
+	for i := 1; i < callers; i++ {
+		if reports[i] != reports[0] {
+			t.Fatalf("caller %d got another report", i)
+		}
+	}
+}
+
+func TestCheckerIgnoresCancelledProbe(t *testing.T) {
+	c := NewChecker(time.Second, time.Hour)
+	c.Add("postgres", func(ctx context.Context) error { return ctx.Err() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := c.Check(ctx); report.Status != StatusUp {
+		t.Errorf("Status = %s, a cancelled probe must not be cached as a failure", report.Status)
+	}
+}
@@ -9,7 +9,13 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/labstack/echo/v4"
+
+	"github.com/Lidne/praktika_MAI/pkg/logger"
 )
 
 const (
@@ -25,6 +31,17 @@ const (
 	ErrInvalidEmail     = "Invalid email"
 	ErrInvalidPassword  = "Invalid password"
 	ErrInvalidField     = "Invalid field"
+	ErrUnprocessable    = "Unprocessable Entity"
+)
+
+// postgres error codes, https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgNotNullViolation    = "23502"
+	pgCheckViolation      = "23514"
+	pgDataExceptionClass  = "22"
+	pgQueryCanceled       = "57014"
 )
 
 var (
@@ -157,53 +174,105 @@ func NewInternalServerError(causes interface{}) RestErr {
 	return result
 }
 
-// Parser of error string messages returns RestError
+// ParseErrors map known error types and sentinels to RestError, any other error is an internal server error
+// whose details are not sent to clients
 func ParseErrors(err error) RestErr {
+	var (
+		restErr        RestErr
+		httpErr        *echo.HTTPError
+		pgErr          *pgconn.PgError
+		validationErrs validator.ValidationErrors
+		jwtErr         *jwt.ValidationError
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+	)
 	switch {
-	case errors.Is(err, sql.ErrNoRows):
+	case errors.As(err, &restErr):
+		return restErr
+	case errors.As(err, &httpErr):
+		return NewRestError(httpErr.Code, http.StatusText(httpErr.Code), fmt.Sprint(httpErr.Message))
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, pgx.ErrNoRows):
 		return NewRestError(http.StatusNotFound, ErrNotFound, nil)
-	case errors.Is(err, context.DeadlineExceeded):
+	case errors.Is(err, context.DeadlineExceeded), pgconn.Timeout(err):
 		return NewRestError(http.StatusRequestTimeout, ErrRequestTimeout, nil)
+	case errors.As(err, &pgErr):
+		return parseSqlErrors(pgErr)
+	case errors.As(err, &validationErrs):
+		return parseValidatorError(validationErrs)
 	case errors.Is(err, Unauthorized):
 		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, nil)
 	case errors.Is(err, WrongCredentials):
-		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, nil)
-	case strings.Contains(strings.ToLower(err.Error()), "sqlstate"):
-		return parseSqlErrors(err)
-	case strings.Contains(strings.ToLower(err.Error()), "field validation"):
-		return parseValidatorError(err)
-	case strings.Contains(strings.ToLower(err.Error()), "unmarshal"):
-		return NewRestError(http.StatusBadRequest, ErrBadRequest, err)
-	case strings.Contains(strings.ToLower(err.Error()), "uuid"):
-		return NewRestError(http.StatusBadRequest, ErrBadRequest, err)
-	case strings.Contains(strings.ToLower(err.Error()), "cookie"):
-		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, err)
-	case strings.Contains(strings.ToLower(err.Error()), "token"):
-		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, err)
-	case strings.Contains(strings.ToLower(err.Error()), "bcrypt"):
-		return NewRestError(http.StatusBadRequest, ErrBadRequest, nil)
+		return NewRestError(http.StatusUnauthorized, ErrWrongCredentials, nil)
+	case errors.Is(err, CSRFNotPresented), errors.Is(err, WrongCSRFToken), errors.Is(err, ExpiredCSRFError):
+		return NewRestError(http.StatusForbidden, ErrForbidden, err.Error())
+	case errors.Is(err, InvalidJWTToken), errors.Is(err, InvalidJWTClaims):
+		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, err.Error())
+	case errors.As(err, &jwtErr):
+		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, jwtErr.Error())
+	case errors.Is(err, http.ErrNoCookie):
+		return NewRestError(http.StatusUnauthorized, ErrUnauthorized, NoCookie.Error())
+	case errors.As(err, &syntaxErr):
+		return NewRestError(http.StatusBadRequest, ErrBadRequest, syntaxErr.Error())
+	case errors.As(err, &typeErr):
+		return NewRestError(http.StatusBadRequest, ErrBadRequest, typeErr.Error())
 	default:
-		if restErr, ok := err.(RestErr); ok {
-			return restErr
-		}
-		return NewInternalServerError(err)
+		return NewInternalServerError(nil)
+	}
+}
+
+// parseSqlErrors map postgres error codes, details of unexpected errors are not sent to clients
+func parseSqlErrors(err *pgconn.PgError) RestErr {
+	switch {
+	case err.Code == pgUniqueViolation:
+		return NewRestError(http.StatusConflict, ErrAlreadyExists, err.Detail)
+	case err.Code == pgForeignKeyViolation:
+		return NewRestError(http.StatusUnprocessableEntity, ErrUnprocessable, err.Detail)
+	case err.Code == pgNotNullViolation, err.Code == pgCheckViolation, strings.HasPrefix(err.Code, pgDataExceptionClass):
+		return NewRestError(http.StatusBadRequest, ErrBadRequest, err.Message)
+	case err.Code == pgQueryCanceled:
+		return NewRestError(http.StatusRequestTimeout, ErrRequestTimeout, nil)
+	default:
+		return NewInternalServerError(nil)
 	}
 }
 
-func parseSqlErrors(err error) RestErr {
-	return NewRestError(http.StatusBadRequest, ErrBadRequest, err)
+// FieldError failed validation rule of a request field
+type FieldError struct {
+	Field string `json:"field"`
+	Tag   string `json:"tag"`
+	Param string `json:"param,omitempty"`
 }
 
-func parseValidatorError(err error) RestErr {
-	if strings.Contains(err.Error(), "Password") {
-		return NewRestError(http.StatusBadRequest, ErrInvalidPassword, err)
+func parseValidatorError(errs validator.ValidationErrors) RestErr {
+	causes := make([]FieldError, 0, len(errs))
+	for _, e := range errs {
+		causes = append(causes, FieldError{Field: e.Field(), Tag: e.Tag(), Param: e.Param()})
 	}
+	return NewRestError(http.StatusBadRequest, ErrInvalidField, causes)
+}
 
-	if strings.Contains(err.Error(), "Email") {
-		return NewRestError(http.StatusBadRequest, ErrInvalidEmail, err)
-	}
+// NewHTTPErrorHandler echo error handler writing every error returned by handlers and middlewares as RestError
+func NewHTTPErrorHandler(log logger.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
 
-	return NewRestError(http.StatusBadRequest, ErrInvalidField, err)
+		log := logger.FromContext(c.Request().Context(), log)
+		restErr := ParseErrors(err)
+		if restErr.Status() >= http.StatusInternalServerError {
+			log.Errorf("%s %s: %v", c.Request().Method, c.Request().URL.Path, err)
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(restErr.Status())
+		} else {
+			err = c.JSON(restErr.Status(), restErr.ErrBody())
+		}
+		if err != nil {
+			log.Errorf("HTTPErrorHandler: %v", err)
+		}
+	}
 }
 
 // Error response
@@ -0,0 +1,200 @@
+package httpErrors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantError  string
+		wantCauses interface{}
+	}{
+		{
+			name:       "unique violation",
+			err:        &pgconn.PgError{Code: "23505", Message: "duplicate key", Detail: "Key (login)=(bob) already exists."},
+			wantStatus: http.StatusConflict,
+			wantError:  ErrAlreadyExists,
+			wantCauses: "Key (login)=(bob) already exists.",
+		},
+		{
+			name:       "wrapped unique violation",
+			err:        fmt.Errorf("userRepo.Create.Exec: %w", &pgconn.PgError{Code: "23505", Detail: "Key (login)=(bob) already exists."}),
+			wantStatus: http.StatusConflict,
+			wantError:  ErrAlreadyExists,
+			wantCauses: "Key (login)=(bob) already exists.",
+		},
+		{
+			name:       "foreign key violation",
+			err:        &pgconn.PgError{Code: "23503", Detail: "Key (product_id)=(9) is not present."},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantError:  ErrUnprocessable,
+			wantCauses: "Key (product_id)=(9) is not present.",
+		},
+		{
+			name:       "not null violation",
+			err:        &pgconn.PgError{Code: "23502", Message: "null value in column \"name\"", Detail: "Failing row contains (secret)."},
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrBadRequest,
+			wantCauses: "null value in column \"name\"",
+		},
+		{
+			name:       "check violation",
+			err:        &pgconn.PgError{Code: "23514", Message: "violates check constraint \"price_positive\""},
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrBadRequest,
+			wantCauses: "violates check constraint \"price_positive\"",
+		},
+		{
+			name:       "data exception",
+			err:        &pgconn.PgError{Code: "22P02", Message: "invalid input syntax for type integer"},
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrBadRequest,
+			wantCauses: "invalid input syntax for type integer",
+		},
+		{
+			name:       "query canceled",
+			err:        &pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"},
+			wantStatus: http.StatusRequestTimeout,
+			wantError:  ErrRequestTimeout,
+		},
+		{
+			name:       "unexpected postgres error hides details",
+			err:        &pgconn.PgError{Code: "42P01", Message: "relation \"products\" does not exist"},
+			wantStatus: http.StatusInternalServerError,
+			wantError:  InternalServerError.Error(),
+		},
+		{
+			name:       "no rows",
+			err:        fmt.Errorf("productRepo.GetByID: %w", pgx.ErrNoRows),
+			wantStatus: http.StatusNotFound,
+			wantError:  ErrNotFound,
+		},
+		{
+			name:       "sql no rows",
+			err:        sql.ErrNoRows,
+			wantStatus: http.StatusNotFound,
+			wantError:  ErrNotFound,
+		},
+		{
+			name:       "deadline exceeded",
+			err:        fmt.Errorf("query: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusRequestTimeout,
+			wantError:  ErrRequestTimeout,
+		},
+		{
+			name:       "rest error is kept",
+			err:        NewForbiddenError("not yours"),
+			wantStatus: http.StatusForbidden,
+			wantError:  Forbidden.Error(),
+			wantCauses: "not yours",
+		},
+		{
+			name:       "echo error",
+			err:        echo.NewHTTPError(http.StatusMethodNotAllowed, "method not allowed"),
+			wantStatus: http.StatusMethodNotAllowed,
+			wantError:  http.StatusText(http.StatusMethodNotAllowed),
+			wantCauses: "method not allowed",
+		},
+		{
+			name:       "wrong credentials",
+			err:        WrongCredentials,
+			wantStatus: http.StatusUnauthorized,
+			wantError:  ErrWrongCredentials,
+		},
+		{
+			name:       "expired csrf token",
+			err:        ExpiredCSRFError,
+			wantStatus: http.StatusForbidden,
+			wantError:  ErrForbidden,
+			wantCauses: ExpiredCSRFError.Error(),
+		},
+		{
+			name:       "jwt validation error",
+			err:        fmt.Errorf("parse: %w", jwt.NewValidationError("token is expired", jwt.ValidationErrorExpired)),
+			wantStatus: http.StatusUnauthorized,
+			wantError:  ErrUnauthorized,
+			wantCauses: "token is expired",
+		},
+		{
+			name:       "no cookie",
+			err:        fmt.Errorf("c.Cookie: %w", http.ErrNoCookie),
+			wantStatus: http.StatusUnauthorized,
+			wantError:  ErrUnauthorized,
+			wantCauses: NoCookie.Error(),
+		},
+		{
+			name:       "json syntax error",
+			err:        json.Unmarshal([]byte(`{"name": `), &struct{}{}),
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrBadRequest,
+			wantCauses: "unexpected end of JSON input",
+		},
+		{
+			name:       "json type error",
+			err:        fmt.Errorf("decode: %w", json.Unmarshal([]byte(`{"price": "free"}`), &struct{ Price int }{})),
+			wantStatus: http.StatusBadRequest,
+			wantError:  ErrBadRequest,
+			wantCauses: "json: cannot unmarshal string into Go struct field .price of type int",
+		},
+		{
+			name:       "message about a token is not an auth error",
+			err:        errors.New("redis: invalid token in reply"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  InternalServerError.Error(),
+		},
+		{
+			name:       "message about a cookie is not an auth error",
+			err:        errors.New("sessionRepo.Touch: cookie store unavailable"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  InternalServerError.Error(),
+		},
+		{
+			name:       "message about unmarshal is not a bad request",
+			err:        errors.New("cache: failed to unmarshal cached value"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  InternalServerError.Error(),
+		},
+		{
+			name:       "bcrypt error is internal",
+			err:        errors.New("bcrypt.GenerateFromPassword: bcrypt: password length exceeds 72 bytes"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  InternalServerError.Error(),
+		},
+		{
+			name:       "unexpected error hides details",
+			err:        errors.New("dial tcp 10.0.0.5:5432: connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  InternalServerError.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseErrors(tt.err)
+			if got.Status() != tt.wantStatus {
+				t.Errorf("Status() = %d, want %d", got.Status(), tt.wantStatus)
+			}
+			if got.ErrBody().ErrError != tt.wantError {
+				t.Errorf("error = %q, want %q", got.ErrBody().ErrError, tt.wantError)
+			}
+			if !reflect.DeepEqual(got.Causes(), tt.wantCauses) {
+				t.Errorf("Causes() = %#v, want %#v", got.Causes(), tt.wantCauses)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package httpUtils
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	httpErrors "github.com/Lidne/praktika_MAI/pkg/http_errors"
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+// PaginationQuery parse limit, after and sort query params of list endpoints
+func PaginationQuery(c echo.Context) (*pagination.Query, error) {
+	return pagination.NewQuery(c.QueryParam("limit"), c.QueryParam("after"), c.QueryParam("sort"))
+}
+
+// QueryInt optional int query param, nil when it is absent
+func QueryInt(c echo.Context, name string) (*int, error) {
+	param := c.QueryParam(name)
+	if param == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(param)
+	if err != nil {
+		return nil, errors.Errorf("invalid %s", name)
+	}
+	return &v, nil
+}
+
+// QueryBool optional bool query param, nil when it is absent
+func QueryBool(c echo.Context, name string) (*bool, error) {
+	param := c.QueryParam(name)
+	if param == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(param)
+	if err != nil {
+		return nil, errors.Errorf("invalid %s", name)
+	}
+	return &v, nil
+}
+
+// ListErrorResponse unknown sort field is a bad request, other errors go to the error handler
+func ListErrorResponse(c echo.Context, err error) error {
+	if errors.Is(err, pagination.ErrInvalidSort) {
+		return c.JSON(http.StatusBadRequest, httpErrors.NewBadRequestError(err.Error()))
+	}
+	return err
+}
+
+// ForbiddenResponse the authenticated user may not access the resource
+func ForbiddenResponse(c echo.Context) error {
+	return c.JSON(http.StatusForbidden, httpErrors.NewForbiddenError(httpErrors.PermissionDenied.Error()))
+}
@@ -31,3 +31,11 @@ func InitJaeger(cfg *config.Config) (opentracing.Tracer, io.Closer, error) {
 		jaegercfg.Metrics(metrics.NullFactory),
 	)
 }
+
+// TraceID id of the trace the span belongs to, empty for spans of other tracers
+func TraceID(span opentracing.Span) string {
+	if spanCtx, ok := span.Context().(jaeger.SpanContext); ok {
+		return spanCtx.TraceID().String()
+	}
+	return ""
+}
@@ -2,12 +2,66 @@ package kafka
 
 import (
 	"context"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/compress"
 
 	"github.com/Lidne/praktika_MAI/config"
 )
 
+const (
+	writerReadTimeout  = 10 * time.Second
+	writerWriteTimeout = 10 * time.Second
+	writerMaxAttempts  = 3
+)
+
 func NewKafkaConn(cfg *config.Config) (*kafka.Conn, error) {
 	return kafka.DialContext(context.Background(), "tcp", cfg.Kafka.Brokers[0])
 }
+
+// Ping read cluster metadata from the first reachable broker
+func Ping(ctx context.Context, brokers []string) error {
+	if len(brokers) == 0 {
+		return errors.New("no kafka brokers configured")
+	}
+	var err error
+	for _, broker := range brokers {
+		if err = pingBroker(ctx, broker); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func pingBroker(ctx context.Context, broker string) error {
+	conn, err := kafka.DialContext(ctx, "tcp", broker)
+	if err != nil {
+		return errors.Wrap(err, "kafka.DialContext")
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return errors.Wrap(err, "conn.SetDeadline")
+		}
+	}
+	if _, err := conn.Brokers(); err != nil {
+		return errors.Wrap(err, "conn.Brokers")
+	}
+	return nil
+}
+
+// NewKafkaWriter writer without a default topic, every message must have its Topic set
+func NewKafkaWriter(cfg *config.Config) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Kafka.Brokers...),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		MaxAttempts:  writerMaxAttempts,
+		Compression:  compress.Snappy,
+		ReadTimeout:  writerReadTimeout,
+		WriteTimeout: writerWriteTimeout,
+	}
+}
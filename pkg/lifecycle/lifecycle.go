@@ -0,0 +1,161 @@
+package lifecycle
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+const defaultShutdownTimeout = 15 * time.Second
+
+// ErrWorkerExited a worker returned before it was stopped
+var ErrWorkerExited = errors.New("worker exited before shutdown")
+
+// component long running part of the application
+type component struct {
+	name string
+	// start blocks until the component is stopped, nil means a graceful stop
+	start func() error
+	// stop asks start to return, ctx bounds the drain
+	stop func(ctx context.Context) error
+}
+
+type closer struct {
+	name  string
+	close func() error
+}
+
+type reloader struct {
+	name   string
+	reload func() error
+}
+
+// Manager starts components concurrently and on signal or failure of any component
+// stops them in reverse order, then closes resources in the order they were added
+type Manager struct {
+	log             logger.Logger
+	shutdownTimeout time.Duration
+	components      []component
+	closers         []closer
+	reloaders       []reloader
+}
+
+// NewManager Manager constructor, non positive timeout means the default of 15 seconds
+func NewManager(log logger.Logger, shutdownTimeout time.Duration) *Manager {
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+	return &Manager{log: log, shutdownTimeout: shutdownTimeout}
+}
+
+// Add component, start must block until stop is called
+func (m *Manager) Add(name string, start func() error, stop func(ctx context.Context) error) {
+	m.components = append(m.components, component{name: name, start: start, stop: stop})
+}
+
+// AddWorker component running until its context is cancelled, a worker returning earlier
+// is reported as a failure and shuts the application down
+func (m *Manager) AddWorker(name string, run func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.Add(name, func() error {
+		run(ctx)
+		if ctx.Err() == nil {
+			return ErrWorkerExited
+		}
+		return nil
+	}, func(context.Context) error {
+		cancel()
+		return nil
+	})
+}
+
+// OnClose resource closed after every component is stopped
+func (m *Manager) OnClose(name string, close func() error) {
+	m.closers = append(m.closers, closer{name: name, close: close})
+}
+
+// OnReload function called on SIGHUP, e.g. to read certificates again
+func (m *Manager) OnReload(name string, reload func() error) {
+	m.reloaders = append(m.reloaders, reloader{name: name, reload: reload})
+}
+
+// Run start components and block until SIGINT, SIGTERM, ctx is done or a component fails,
+// returns the error of the failed component. SIGHUP runs the reload functions
+func (m *Manager) Run(ctx context.Context) error {
+	ctx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	errCh := make(chan error, len(m.components))
+	done := make([]chan struct{}, len(m.components))
+	for i, c := range m.components {
+		done[i] = make(chan struct{})
+		go func(c component, done chan struct{}) {
+			defer close(done)
+			m.log.Infof("Starting %s", c.name)
+			if err := c.start(); err != nil {
+				errCh <- errors.Wrap(err, c.name)
+			}
+		}(c, done[i])
+	}
+
+	var runErr error
+wait:
+	for {
+		select {
+		case <-hup:
+			m.reload()
+		case <-ctx.Done():
+			m.log.Info("Shutdown signal received")
+			break wait
+		case runErr = <-errCh:
+			m.log.Errorf("Shutting down after failure: %v", runErr)
+			break wait
+		}
+	}
+	// a second signal kills the process
+	stopSignals()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+	for i := len(m.components) - 1; i >= 0; i-- {
+		c := m.components[i]
+		m.log.Infof("Stopping %s", c.name)
+		if err := c.stop(shutdownCtx); err != nil {
+			m.log.Errorf("%s stop: %v", c.name, err)
+		}
+		select {
+		case <-done[i]:
+		case <-shutdownCtx.Done():
+			m.log.Errorf("%s did not stop within %v", c.name, m.shutdownTimeout)
+		}
+	}
+
+	for _, cl := range m.closers {
+		if err := cl.close(); err != nil {
+			m.log.Errorf("%s close: %v", cl.name, err)
+		}
+	}
+	m.log.Info("Server Exited Properly")
+	return runErr
+}
+
+func (m *Manager) reload() {
+	m.log.Info("Reload signal received")
+	for _, r := range m.reloaders {
+		if err := r.reload(); err != nil {
+			m.log.Errorf("%s reload: %v", r.name, err)
+			continue
+		}
+		m.log.Infof("%s reloaded", r.name)
+	}
+}
@@ -0,0 +1,18 @@
+package logger
+
+import "context"
+
+type ctxKey struct{}
+
+// NewContext context carrying the logger
+func NewContext(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, log)
+}
+
+// FromContext logger of the context, fallback when the context has none
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if log, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return log
+	}
+	return fallback
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 
 	"go.uber.org/zap"
@@ -25,12 +26,16 @@ type Logger interface {
 	Fatal(args ...interface{})
 	Fatalf(template string, args ...interface{})
 	Printf(template string, args ...interface{})
+	With(args ...interface{}) Logger
+	Level() string
+	SetLevel(level string) error
 }
 
 // Logger
 type apiLogger struct {
 	cfg         *config.Config
 	sugarLogger *zap.SugaredLogger
+	level       zap.AtomicLevel
 }
 
 // NewApiLogger App Logger constructor
@@ -85,7 +90,8 @@ func (l *apiLogger) InitLogger() {
 	}
 
 	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
-	core := zapcore.NewCore(encoder, logWriter, zap.NewAtomicLevelAt(logLevel))
+	l.level = zap.NewAtomicLevelAt(logLevel)
+	core := zapcore.NewCore(encoder, logWriter, l.level)
 	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 
 	l.sugarLogger = logger.Sugar()
@@ -94,6 +100,26 @@ func (l *apiLogger) InitLogger() {
 	}
 }
 
+// With child logger adding the key-value pairs to every entry, it shares the level of the parent
+func (l *apiLogger) With(args ...interface{}) Logger {
+	return &apiLogger{cfg: l.cfg, sugarLogger: l.sugarLogger.With(args...), level: l.level}
+}
+
+// Level current level name
+func (l *apiLogger) Level() string {
+	return l.level.Level().String()
+}
+
+// SetLevel change the level of the logger and all its children at runtime
+func (l *apiLogger) SetLevel(level string) error {
+	logLevel, exist := loggerLevelMap[level]
+	if !exist {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+	l.level.SetLevel(logLevel)
+	return nil
+}
+
 // Logger methods
 
 func (l *apiLogger) Debug(args ...interface{}) {
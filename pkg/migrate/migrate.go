@@ -0,0 +1,244 @@
+package migrate
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+
+	"github.com/Lidne/praktika_MAI/pkg/logger"
+)
+
+const (
+	migrationsTable = "schema_migrations"
+	// advisoryLockID serializes migrators started concurrently by several instances
+	advisoryLockID = 7345_2024
+)
+
+var (
+	ErrInvalidFileName  = errors.New("invalid migration file name")
+	ErrNoDownMigration  = errors.New("migration has no down script")
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+)
+
+// Migration single schema version
+type Migration struct {
+	Version   int64
+	Name      string
+	Up        string
+	Down      string
+	AppliedAt *time.Time
+}
+
+// Migrator applies migrations from fs to the database
+type Migrator struct {
+	pool       *pgxpool.Pool
+	log        logger.Logger
+	migrations []*Migration
+}
+
+// NewMigrator Migrator constructor, reads <version>_<name>.up.sql and .down.sql files from fsys
+func NewMigrator(pool *pgxpool.Pool, log logger.Logger, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{pool: pool, log: log, migrations: migrations}, nil
+}
+
+func load(fsys fs.FS) ([]*Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, errors.Wrap(err, "fs.Glob")
+	}
+
+	byVersion := map[int64]*Migration{}
+	// seen version and direction of the files read so far, e.g. "1.up"
+	seen := map[string]string{}
+	for _, file := range files {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+		parts := strings.SplitN(base, "_", 2)
+		if len(parts) != 2 || (direction != ".up" && direction != ".down") {
+			return nil, errors.Wrap(ErrInvalidFileName, file)
+		}
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(ErrInvalidFileName, file)
+		}
+
+		key := strconv.FormatInt(version, 10) + direction
+		if other, ok := seen[key]; ok {
+			return nil, errors.Wrapf(ErrDuplicateVersion, "%s and %s", other, file)
+		}
+		seen[key] = file
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, errors.Wrap(err, "fs.ReadFile")
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		} else if m.Name != parts[1] {
+			return nil, errors.Wrapf(ErrDuplicateVersion, "%s does not match the name %s", file, m.Name)
+		}
+		if direction == ".up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, errors.Errorf("migration %d has no up script", m.Version)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// withLock run fn on a single connection holding the migrations advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "pool.Acquire")
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockID); err != nil {
+		return errors.Wrap(err, "pg_advisory_lock")
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, advisoryLockID); err != nil {
+			m.log.Errorf("pg_advisory_unlock: %v", err)
+		}
+	}()
+
+	q := `CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (
+		version    BIGINT PRIMARY KEY,
+		name       TEXT      NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')
+	)`
+	if _, err := conn.Exec(ctx, q); err != nil {
+		return errors.Wrap(err, "create migrations table")
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM `+migrationsTable)
+	if err != nil {
+		return nil, errors.Wrap(err, "select applied migrations")
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, errors.Wrap(err, "scan applied migration")
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// run execute script and record the version change in one transaction
+func (m *Migrator) run(ctx context.Context, conn *pgxpool.Conn, script, record string, args ...any) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, script); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, record, args...)
+		return err
+	})
+}
+
+// Up apply all pending migrations, returns number of applied migrations
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			record := `INSERT INTO ` + migrationsTable + ` (version, name) VALUES ($1, $2)`
+			if err := m.run(ctx, conn, mig.Up, record, mig.Version, mig.Name); err != nil {
+				return errors.Wrapf(err, "migration %d_%s up", mig.Version, mig.Name)
+			}
+			m.log.Infof("Migration applied: %d_%s", mig.Version, mig.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down roll back up to steps latest applied migrations, returns number of rolled back migrations
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return errors.Wrapf(ErrNoDownMigration, "migration %d_%s", mig.Version, mig.Name)
+			}
+			record := `DELETE FROM ` + migrationsTable + ` WHERE version = $1`
+			if err := m.run(ctx, conn, mig.Down, record, mig.Version); err != nil {
+				return errors.Wrapf(err, "migration %d_%s down", mig.Version, mig.Name)
+			}
+			m.log.Infof("Migration rolled back: %d_%s", mig.Version, mig.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status all known migrations, AppliedAt is nil for pending ones
+func (m *Migrator) Status(ctx context.Context) ([]*Migration, error) {
+	var status []*Migration
+	err := m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			s := *mig
+			if appliedAt, ok := applied[mig.Version]; ok {
+				s.AppliedAt = &appliedAt
+			}
+			status = append(status, &s)
+		}
+		return nil
+	})
+	return status, err
+}
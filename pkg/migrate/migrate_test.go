@@ -0,0 +1,154 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Lidne/praktika_MAI/migrations"
+)
+
+func file(body string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(body)}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		fsys        fstest.MapFS
+		wantVersion []int64
+		wantNames   []string
+		wantErr     error
+		wantAnyErr  bool
+	}{
+		{
+			name: "ordered by numeric version",
+			fsys: fstest.MapFS{
+				"10_tenth.up.sql":      file("CREATE TABLE ten ();"),
+				"2_second.up.sql":      file("CREATE TABLE two ();"),
+				"2_second.down.sql":    file("DROP TABLE two;"),
+				"000001_init.up.sql":   file("CREATE TABLE one ();"),
+				"000001_init.down.sql": file("DROP TABLE one;"),
+				"README.md":            file("not a migration"),
+			},
+			wantVersion: []int64{1, 2, 10},
+			wantNames:   []string{"init", "second", "tenth"},
+		},
+		{
+			name: "name with underscores",
+			fsys: fstest.MapFS{
+				"000003_outbox_claim.up.sql": file("ALTER TABLE outbox ADD COLUMN claimed_until TIMESTAMP;"),
+			},
+			wantVersion: []int64{3},
+			wantNames:   []string{"outbox_claim"},
+		},
+		{name: "empty", fsys: fstest.MapFS{}, wantVersion: []int64{}},
+		{
+			name:    "no version",
+			fsys:    fstest.MapFS{"init.up.sql": file("SELECT 1;")},
+			wantErr: ErrInvalidFileName,
+		},
+		{
+			name:    "version not a number",
+			fsys:    fstest.MapFS{"v1_init.up.sql": file("SELECT 1;")},
+			wantErr: ErrInvalidFileName,
+		},
+		{
+			name:    "no direction",
+			fsys:    fstest.MapFS{"000001_init.sql": file("SELECT 1;")},
+			wantErr: ErrInvalidFileName,
+		},
+		{
+			name: "same version twice",
+			fsys: fstest.MapFS{
+				"000001_init.up.sql":  file("CREATE TABLE one ();"),
+				"000001_users.up.sql": file("CREATE TABLE users ();"),
+			},
+			wantErr: ErrDuplicateVersion,
+		},
+		{
+			name: "same version with another padding",
+			fsys: fstest.MapFS{
+				"000001_init.up.sql": file("CREATE TABLE one ();"),
+				"1_init.up.sql":      file("CREATE TABLE one ();"),
+			},
+			wantErr: ErrDuplicateVersion,
+		},
+		{
+			name: "down of another name",
+			fsys: fstest.MapFS{
+				"000001_init.up.sql":    file("CREATE TABLE one ();"),
+				"000001_users.down.sql": file("DROP TABLE users;"),
+			},
+			wantErr: ErrDuplicateVersion,
+		},
+		{
+			name: "empty up repeated",
+			fsys: fstest.MapFS{
+				"000001_init.up.sql": file(""),
+				"01_init.up.sql":     file("CREATE TABLE one ();"),
+			},
+			wantErr: ErrDuplicateVersion,
+		},
+		{
+			name:       "down without up",
+			fsys:       fstest.MapFS{"000001_init.down.sql": file("DROP TABLE one;")},
+			wantAnyErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := load(tt.fsys)
+			if tt.wantErr != nil || tt.wantAnyErr {
+				if err == nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("load() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("load() error = %v", err)
+			}
+			if len(got) != len(tt.wantVersion) {
+				t.Fatalf("load() = %d migrations, want %d", len(got), len(tt.wantVersion))
+			}
+			for i, m := range got {
+				if m.Version != tt.wantVersion[i] || m.Name != tt.wantNames[i] {
+					t.Errorf("migration %d = %d_%s, want %d_%s", i, m.Version, m.Name, tt.wantVersion[i], tt.wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadScripts(t *testing.T) {
+	got, err := load(fstest.MapFS{
+		"000001_init.up.sql":   file("CREATE TABLE one ();"),
+		"000001_init.down.sql": file("DROP TABLE one;"),
+		"000002_index.up.sql":  file("CREATE INDEX one_idx ON one (id);"),
+	})
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if got[0].Up != "CREATE TABLE one ();" || got[0].Down != "DROP TABLE one;" {
+		t.Errorf("migration 1 scripts = %q, %q", got[0].Up, got[0].Down)
+	}
+	if got[1].Down != "" {
+		t.Errorf("migration 2 down = %q, want empty", got[1].Down)
+	}
+}
+
+func TestRepositoryMigrations(t *testing.T) {
+	got, err := load(migrations.FS)
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	for i, m := range got {
+		if m.Version != int64(i+1) {
+			t.Errorf("migration %s has version %d, want %d, versions must have no gaps", m.Name, m.Version, i+1)
+		}
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+		}
+	}
+}
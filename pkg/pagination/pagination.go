@@ -0,0 +1,155 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	defaultSort  = "id"
+	defaultPage  = 1
+)
+
+var (
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort field")
+	ErrInvalidPage   = errors.New("invalid page")
+)
+
+// Cursor position of the last returned row in a keyset ordered list
+type Cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// Encode opaque string representation of the cursor
+func (c *Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parse cursor returned by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := &Cursor{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Query keyset pagination and sorting params
+type Query struct {
+	Limit int
+	After *Cursor
+	Sort  string
+	Desc  bool
+}
+
+// NewQuery parse limit, after and sort params, "-" prefix of sort means descending order
+func NewQuery(limit, after, sort string) (*Query, error) {
+	q := &Query{Limit: defaultLimit, Sort: defaultSort}
+
+	if limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l <= 0 || l > maxLimit {
+			return nil, ErrInvalidLimit
+		}
+		q.Limit = l
+	}
+
+	if sort != "" {
+		q.Desc = strings.HasPrefix(sort, "-")
+		q.Sort = strings.TrimPrefix(sort, "-")
+	}
+
+	if after != "" {
+		c, err := DecodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != q.Sort {
+			return nil, errors.Wrap(ErrInvalidCursor, "cursor was issued for another sort field")
+		}
+		if c.Desc != q.Desc {
+			return nil, errors.Wrap(ErrInvalidCursor, "cursor was issued for another sort direction")
+		}
+		q.After = c
+	}
+
+	return q, nil
+}
+
+// Next cursor pointing after the row with given sort value and id
+func (q *Query) Next(value string, id int) string {
+	c := &Cursor{Sort: q.Sort, Desc: q.Desc, Value: value, ID: id}
+	return c.Encode()
+}
+
+// PageQuery page number based pagination params, pages are numbered from 1
+type PageQuery struct {
+	Page int64
+	Size int64
+}
+
+// NewPageQuery validate page and size, zero values are replaced with defaults
+func NewPageQuery(page, size int64) (*PageQuery, error) {
+	q := &PageQuery{Page: page, Size: size}
+	if q.Page == 0 {
+		q.Page = defaultPage
+	}
+	if q.Size == 0 {
+		q.Size = defaultLimit
+	}
+	if q.Page < 0 {
+		return nil, ErrInvalidPage
+	}
+	if q.Size < 0 || q.Size > maxLimit {
+		return nil, ErrInvalidLimit
+	}
+	return q, nil
+}
+
+// ParsePageQuery parse page and size query params
+func ParsePageQuery(page, size string) (*PageQuery, error) {
+	var p, s int64
+	var err error
+	if page != "" {
+		if p, err = strconv.ParseInt(page, 10, 64); err != nil {
+			return nil, ErrInvalidPage
+		}
+	}
+	if size != "" {
+		if s, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return nil, ErrInvalidLimit
+		}
+	}
+	return NewPageQuery(p, s)
+}
+
+// Offset number of rows to skip
+func (q *PageQuery) Offset() int64 {
+	return (q.Page - 1) * q.Size
+}
+
+// TotalPages number of pages needed to show totalCount rows
+func (q *PageQuery) TotalPages(totalCount int64) int64 {
+	return (totalCount + q.Size - 1) / q.Size
+}
+
+// HasMore whether there are pages after the current one
+func (q *PageQuery) HasMore(totalCount int64) bool {
+	return q.Page < q.TotalPages(totalCount)
+}
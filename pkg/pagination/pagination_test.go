@@ -0,0 +1,106 @@
+package pagination
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{name: "id", cursor: Cursor{Sort: "id", Value: "42", ID: 42}},
+		{name: "text with separators", cursor: Cursor{Sort: "name", Value: `a "quoted", name/with+chars`, ID: 3}},
+		{name: "empty value", cursor: Cursor{Sort: "price", ID: 1}},
+		{name: "descending", cursor: Cursor{Sort: "price", Desc: true, Value: "10", ID: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("DecodeCursor() error = %v", err)
+			}
+			if *got != tt.cursor {
+				t.Errorf("DecodeCursor() = %+v, want %+v", *got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "not base64", input: "***"},
+		{name: "padded base64", input: "eyJzIjoiaWQifQ=="},
+		{name: "not json", input: "bm90IGpzb24"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.input); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("DecodeCursor() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestNewQuery(t *testing.T) {
+	byName := (&Cursor{Sort: "name", Value: "b", ID: 2}).Encode()
+	byNameDesc := (&Cursor{Sort: "name", Desc: true, Value: "b", ID: 2}).Encode()
+
+	tests := []struct {
+		name    string
+		limit   string
+		after   string
+		sort    string
+		want    Query
+		wantErr error
+	}{
+		{name: "defaults", want: Query{Limit: defaultLimit, Sort: defaultSort}},
+		{name: "limit", limit: "5", want: Query{Limit: 5, Sort: defaultSort}},
+		{name: "max limit", limit: "100", want: Query{Limit: maxLimit, Sort: defaultSort}},
+		{name: "descending", sort: "-price", want: Query{Limit: defaultLimit, Sort: "price", Desc: true}},
+		{name: "cursor", sort: "name", after: byName, want: Query{Limit: defaultLimit, Sort: "name", After: &Cursor{Sort: "name", Value: "b", ID: 2}}},
+		{name: "zero limit", limit: "0", wantErr: ErrInvalidLimit},
+		{name: "limit above max", limit: "101", wantErr: ErrInvalidLimit},
+		{name: "limit not a number", limit: "ten", wantErr: ErrInvalidLimit},
+		{name: "descending cursor", sort: "-name", after: byNameDesc, want: Query{Limit: defaultLimit, Sort: "name", Desc: true, After: &Cursor{Sort: "name", Desc: true, Value: "b", ID: 2}}},
+		{name: "cursor of another sort", sort: "price", after: byName, wantErr: ErrInvalidCursor},
+		{name: "ascending cursor in descending query", sort: "-name", after: byName, wantErr: ErrInvalidCursor},
+		{name: "descending cursor in ascending query", sort: "name", after: byNameDesc, wantErr: ErrInvalidCursor},
+		{name: "broken cursor", after: "***", wantErr: ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewQuery(tt.limit, tt.after, tt.sort)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("NewQuery() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got.Limit != tt.want.Limit || got.Sort != tt.want.Sort || got.Desc != tt.want.Desc {
+				t.Errorf("NewQuery() = %+v, want %+v", *got, tt.want)
+			}
+			if (got.After == nil) != (tt.want.After == nil) || got.After != nil && *got.After != *tt.want.After {
+				t.Errorf("NewQuery().After = %+v, want %+v", got.After, tt.want.After)
+			}
+		})
+	}
+}
+
+func TestQueryNext(t *testing.T) {
+	q := &Query{Limit: 10, Sort: "price", Desc: true}
+	c, err := DecodeCursor(q.Next("9.99", 5))
+	if err != nil {
+		t.Fatalf("DecodeCursor() error = %v", err)
+	}
+	if want := (Cursor{Sort: "price", Desc: true, Value: "9.99", ID: 5}); *c != want {
+		t.Errorf("Next() = %+v, want %+v", *c, want)
+	}
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+// SortColumn column a list can be ordered by and SQL type its cursor value is cast back to
+type SortColumn struct {
+	Column string
+	Type   string
+}
+
+// Builder accumulates AND-ed WHERE conditions with positional arguments
+type Builder struct {
+	conditions []string
+	args       []any
+}
+
+// Arg add argument and return its placeholder
+func (b *Builder) Arg(v any) string {
+	b.args = append(b.args, v)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+// Where add condition
+func (b *Builder) Where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+// Args accumulated arguments
+func (b *Builder) Args() []any {
+	return b.args
+}
+
+// WhereClause WHERE part of the query, empty if there are no conditions
+func (b *Builder) WhereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// Keyset add keyset condition, ordering and limit to the select statement.
+// One extra row is requested so the caller can tell whether there is a next page.
+func (b *Builder) Keyset(selectSQL string, col SortColumn, q *pagination.Query) string {
+	cmp, order := ">", "ASC"
+	if q.Desc {
+		cmp, order = "<", "DESC"
+	}
+	if q.After != nil {
+		b.Where(fmt.Sprintf("(%s, id) %s (%s::text::%s, %s)", col.Column, cmp, b.Arg(q.After.Value), col.Type, b.Arg(q.After.ID)))
+	}
+	return fmt.Sprintf("%s%s ORDER BY %s %s, id %s LIMIT %s", selectSQL, b.WhereClause(), col.Column, order, order, b.Arg(q.Limit+1))
+}
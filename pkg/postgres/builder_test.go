@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Lidne/praktika_MAI/pkg/pagination"
+)
+
+func TestBuilderKeyset(t *testing.T) {
+	const selectSQL = "SELECT id, name FROM products"
+	name := SortColumn{Column: "name", Type: "text"}
+
+	tests := []struct {
+		name     string
+		where    []string
+		query    pagination.Query
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "first page",
+			query:    pagination.Query{Limit: 20, Sort: "name"},
+			wantSQL:  selectSQL + " ORDER BY name ASC, id ASC LIMIT $1",
+			wantArgs: []any{21},
+		},
+		{
+			name:     "descending first page",
+			query:    pagination.Query{Limit: 5, Sort: "name", Desc: true},
+			wantSQL:  selectSQL + " ORDER BY name DESC, id DESC LIMIT $1",
+			wantArgs: []any{6},
+		},
+		{
+			name:     "after cursor",
+			query:    pagination.Query{Limit: 20, Sort: "name", After: &pagination.Cursor{Sort: "name", Value: "b", ID: 2}},
+			wantSQL:  selectSQL + " WHERE (name, id) > ($1::text::text, $2) ORDER BY name ASC, id ASC LIMIT $3",
+			wantArgs: []any{"b", 2, 21},
+		},
+		{
+			name:     "descending after cursor",
+			query:    pagination.Query{Limit: 20, Sort: "name", Desc: true, After: &pagination.Cursor{Sort: "name", Value: "b", ID: 2}},
+			wantSQL:  selectSQL + " WHERE (name, id) < ($1::text::text, $2) ORDER BY name DESC, id DESC LIMIT $3",
+			wantArgs: []any{"b", 2, 21},
+		},
+		{
+			name:     "after cursor with filter",
+			where:    []string{"price > 10"},
+			query:    pagination.Query{Limit: 1, Sort: "name", After: &pagination.Cursor{Sort: "name", Value: "b", ID: 2}},
+			wantSQL:  selectSQL + " WHERE price > 10 AND (name, id) > ($1::text::text, $2) ORDER BY name ASC, id ASC LIMIT $3",
+			wantArgs: []any{"b", 2, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Builder{}
+			for _, cond := range tt.where {
+				b.Where(cond)
+			}
+			if got := b.Keyset(selectSQL, name, &tt.query); got != tt.wantSQL {
+				t.Errorf("Keyset() =\n%s\nwant\n%s", got, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(b.Args(), tt.wantArgs) {
+				t.Errorf("Args() = %v, want %v", b.Args(), tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuilderWhereClause(t *testing.T) {
+	b := &Builder{}
+	if got := b.WhereClause(); got != "" {
+		t.Errorf("WhereClause() = %q, want empty", got)
+	}
+	b.Where("name ILIKE " + b.Arg("%tea%"))
+	b.Where("price <= " + b.Arg(100))
+	if want := " WHERE name ILIKE $1 AND price <= $2"; b.WhereClause() != want {
+		t.Errorf("WhereClause() = %q, want %q", b.WhereClause(), want)
+	}
+}
@@ -19,7 +19,13 @@ type Client interface {
 
 func NewClient(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
 	DBUrl := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", cfg.Postgres.User, cfg.Postgres.Password, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.DB)
-	dbpool, err := pgxpool.New(ctx, DBUrl)
+	poolConfig, err := pgxpool.ParseConfig(DBUrl)
+	if err != nil {
+		return nil, err
+	}
+	poolConfig.ConnConfig.Tracer = &queryTracer{}
+
+	dbpool, err := pgxpool.NewWithConfig(ctx, poolConfig)
 	if err != nil {
 		log.Fatal("Error connecting to database")
 		return nil, err
@@ -0,0 +1,81 @@
+// Package postgrestest migrated database for repository tests
+package postgrestest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/Lidne/praktika_MAI/migrations"
+)
+
+// EnvURL variable with the url of the test database, tests are skipped when it is empty
+const EnvURL = "TEST_POSTGRES_URL"
+
+// New pool on a fresh schema with all up migrations applied, the schema is dropped on cleanup
+func New(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	url := os.Getenv(EnvURL)
+	if url == "" {
+		t.Skip(EnvURL + " is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	schema := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	admin, err := pgxpool.New(ctx, url)
+	if err != nil {
+		t.Fatalf("pgxpool.New: %v", err)
+	}
+	defer admin.Close()
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("CREATE SCHEMA: %v", err)
+	}
+	t.Cleanup(func() {
+		admin, err := pgxpool.New(context.Background(), url)
+		if err != nil {
+			t.Errorf("pgxpool.New: %v", err)
+			return
+		}
+		defer admin.Close()
+		if _, err := admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("DROP SCHEMA: %v", err)
+		}
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("pgxpool.ParseConfig: %v", err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		t.Fatalf("pgxpool.NewWithConfig: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	// versions are zero padded, so names sort in version order
+	files, err := fs.Glob(migrations.FS, "*.up.sql")
+	if err != nil {
+		t.Fatalf("fs.Glob: %v", err)
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		script, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			t.Fatalf("fs.ReadFile %s: %v", name, err)
+		}
+		if _, err := pool.Exec(ctx, string(script)); err != nil {
+			t.Fatalf("migration %s: %v", name, err)
+		}
+	}
+
+	return pool
+}
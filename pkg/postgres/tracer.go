@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+type (
+	statementNameCtxKey struct{}
+	querySpanCtxKey     struct{}
+)
+
+// StartSpan start a repository span, queries made with the returned context are traced as its children
+// and tagged with name as the statement name
+func StartSpan(ctx context.Context, name string) (opentracing.Span, context.Context) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, name)
+	return span, context.WithValue(ctx, statementNameCtxKey{}, name)
+}
+
+// queryTracer pgx tracer starting a span for every query made within a traced request,
+// background queries without a parent span are not traced
+type queryTracer struct{}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if opentracing.SpanFromContext(ctx) == nil {
+		return ctx
+	}
+	span, ctx := opentracing.StartSpanFromContext(ctx, "pgx.Query")
+	ext.DBType.Set(span, "postgresql")
+	ext.DBStatement.Set(span, data.SQL)
+	ext.SpanKindRPCClient.Set(span)
+	if name, ok := ctx.Value(statementNameCtxKey{}).(string); ok {
+		span.SetTag("db.statement_name", name)
+	}
+	return context.WithValue(ctx, querySpanCtxKey{}, span)
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, ok := ctx.Value(querySpanCtxKey{}).(opentracing.Span)
+	if !ok {
+		return
+	}
+	defer span.Finish()
+
+	span.SetTag("db.rows", data.CommandTag.RowsAffected())
+	if data.Err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.Error(data.Err))
+	}
+}
+
+// Finish finish the span of StartSpan with the error the repository method returns, pass the address of the
+// named result from a defer. A missing row is logged but is not an error of the span
+func Finish(span opentracing.Span, err *error) {
+	if *err != nil {
+		ext.Error.Set(span, !errors.Is(*err, pgx.ErrNoRows))
+		span.LogFields(log.Error(*err))
+	}
+	span.Finish()
+}
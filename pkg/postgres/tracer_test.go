@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestFinish(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantError interface{}
+		wantLogs  int
+	}{
+		{name: "success"},
+		{name: "missing row", err: fmt.Errorf("productRepo.GetByID: %w", pgx.ErrNoRows), wantError: false, wantLogs: 1},
+		{name: "failure", err: errors.New("connection refused"), wantError: true, wantLogs: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := mocktracer.New()
+			global := opentracing.GlobalTracer()
+			opentracing.SetGlobalTracer(tracer)
+			defer opentracing.SetGlobalTracer(global)
+
+			parent := tracer.StartSpan("request")
+			ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+			method := func(ctx context.Context) (err error) {
+				span, ctx := StartSpan(ctx, "productRepo.GetByID")
+				defer Finish(span, &err)
+				if name := ctx.Value(statementNameCtxKey{}); name != "productRepo.GetByID" {
+					t.Errorf("statement name = %v, want productRepo.GetByID", name)
+				}
+				return tt.err
+			}
+			if err := method(ctx); err != tt.err {
+				t.Fatalf("method() error = %v, want %v", err, tt.err)
+			}
+
+			spans := tracer.FinishedSpans()
+			if len(spans) != 1 {
+				t.Fatalf("finished %d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.ParentID != parent.Context().(mocktracer.MockSpanContext).SpanID {
+				t.Error("span is not a child of the request span")
+			}
+			if got := span.Tag("error"); got != tt.wantError {
+				t.Errorf("error tag = %v, want %v", got, tt.wantError)
+			}
+			if len(span.Logs()) != tt.wantLogs {
+				t.Errorf("logged %d records, want %d", len(span.Logs()), tt.wantLogs)
+			}
+		})
+	}
+}
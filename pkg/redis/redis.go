@@ -0,0 +1,28 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/Lidne/praktika_MAI/config"
+)
+
+const defaultAddr = ":6379"
+
+// NewRedisClient returns new redis client
+func NewRedisClient(cfg *config.Config) *redis.Client {
+	addr := cfg.Redis.RedisAddr
+	if addr == "" {
+		addr = defaultAddr
+	}
+
+	return redis.NewClient(&redis.Options{
+		Addr:         addr,
+		MinIdleConns: cfg.Redis.MinIdleConn,
+		PoolSize:     cfg.Redis.PoolSize,
+		PoolTimeout:  time.Duration(cfg.Redis.PoolTimeout) * time.Second,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+	})
+}
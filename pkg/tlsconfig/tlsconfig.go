@@ -0,0 +1,148 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Options of a TLS server
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables mutual TLS, clients must present a certificate signed by one of its CAs
+	ClientCAFile string
+	// MinVersion "1.0", "1.1", "1.2" or "1.3", empty means 1.2
+	MinVersion string
+	// CipherSuites names as in crypto/tls, empty means the Go defaults, not configurable for TLS 1.3
+	CipherSuites []string
+	NextProtos   []string
+}
+
+// Reloader server certificate and client CAs that can be reloaded from disk without a restart,
+// new handshakes use the reloaded files while established connections keep the old ones
+type Reloader struct {
+	opts Options
+	base *tls.Config
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// NewServerConfig TLS config of a server reading the certificates through the returned Reloader
+func NewServerConfig(opts Options) (*tls.Config, *Reloader, error) {
+	minVersion, err := ParseVersion(opts.MinVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	cipherSuites, err := ParseCipherSuites(opts.CipherSuites)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := &Reloader{
+		opts: opts,
+		base: &tls.Config{
+			MinVersion:   minVersion,
+			CipherSuites: cipherSuites,
+			NextProtos:   opts.NextProtos,
+		},
+	}
+	if opts.ClientCAFile != "" {
+		r.base.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	if err := r.Reload(); err != nil {
+		return nil, nil, err
+	}
+
+	cfg := r.base.Clone()
+	cfg.GetConfigForClient = r.getConfigForClient
+	return cfg, r, nil
+}
+
+// Reload read the certificate, key and client CAs again, the old ones stay in use on error
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "tls.LoadX509KeyPair")
+	}
+
+	var clientCAs *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return errors.Wrap(err, "os.ReadFile")
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", r.opts.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	return nil
+}
+
+func (r *Reloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cfg := r.base.Clone()
+	cfg.Certificates = []tls.Certificate{*r.cert}
+	cfg.ClientCAs = r.clientCAs
+	return cfg, nil
+}
+
+// ParseVersion TLS version by its number, empty means 1.2
+func ParseVersion(version string) (uint16, error) {
+	if version == "" {
+		return tls.VersionTLS12, nil
+	}
+	v, ok := versions[version]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q, expected 1.0, 1.1, 1.2 or 1.3", version)
+	}
+	return v, nil
+}
+
+// ParseCipherSuites ids of cipher suites by their crypto/tls names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+// Insecure suites are rejected
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	var unknown []string
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown or insecure cipher suites: %s", strings.Join(unknown, ", "))
+	}
+	return ids, nil
+}